@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pauwels-labs/slack-bot/internal/harness"
+	"github.com/pauwels-labs/slack-bot/pkg/slack"
+)
+
+// runHarness simulates Slack against a running bot, sending the commands
+// in a fixture file, or typed one per line, and printing the bot's
+// answers. It returns the process exit code.
+func runHarness(args []string, in io.Reader, out io.Writer) int {
+	flags := flag.NewFlagSet("harness", flag.ContinueOnError)
+	flags.SetOutput(out)
+	target := flags.String("url", "http://127.0.0.1:8080/", "URL the bot serves slash commands on")
+	signingKey := flags.String("signingkey", os.Getenv("APPCFG_SLACK_SIGNINGKEY"), "signing key the bot verifies requests with")
+	fixturesPath := flags.String("fixtures", "", "JSON file of commands to send, commands are read from stdin when empty")
+	replayPath := flags.String("replay", "", "JSON file of a captured request to send again, re-signed with the signing key if there is one")
+	command := flags.String("command", "/bot", "slash command the commands are sent as")
+	user := flags.String("user", "U0HARNESS", "ID of the user sending the commands")
+	channel := flags.String("channel", "C0HARNESS", "ID of the channel the commands are sent from")
+	team := flags.String("team", "T0HARNESS", "ID of the workspace the commands are sent from")
+	settle := flags.Duration("settle", 2*time.Second, "how long to wait for further responses to a command")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	h, err := harness.New(*target, *signingKey, harness.Fixture{
+		Command:   *command,
+		UserID:    *user,
+		UserName:  "harness",
+		ChannelID: *channel,
+		TeamID:    *team,
+	}, *settle)
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return 1
+	}
+	defer h.Close()
+
+	// Replay a captured request
+	if len(*replayPath) > 0 {
+		exchange, err := h.ReplayRequest(context.Background(), *replayPath)
+		if err != nil {
+			fmt.Fprintln(out, err)
+			return 1
+		}
+		printExchange(out, exchange)
+		return 0
+	}
+
+	// Send every fixture in order
+	if len(*fixturesPath) > 0 {
+		fixtures, err := harness.ReadFixtures(*fixturesPath)
+		if err != nil {
+			fmt.Fprintln(out, err)
+			return 1
+		}
+		for _, fixture := range fixtures {
+			fmt.Fprintf(out, "> %s\n", fixture.Text)
+			if !sendAndPrint(h, fixture, out) {
+				return 1
+			}
+		}
+		return 0
+	}
+
+	// Otherwise, send each line typed as the text of a command
+	fmt.Fprintf(out, "sending commands to %s as %s, one per line\n", *target, *command)
+	scanner := bufio.NewScanner(in)
+	for fmt.Fprint(out, "> "); scanner.Scan(); fmt.Fprint(out, "> ") {
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 {
+			continue
+		}
+		sendAndPrint(h, harness.Fixture{Text: text}, out)
+	}
+	fmt.Fprintln(out)
+
+	return 0
+}
+
+// sendAndPrint sends the fixture and prints the bot's answer, reporting
+// whether it could be sent
+func sendAndPrint(h *harness.Harness, fixture harness.Fixture, out io.Writer) bool {
+	exchange, err := h.Send(context.Background(), fixture)
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return false
+	}
+
+	printExchange(out, exchange)
+
+	return true
+}
+
+// printExchange prints the bot's answer to a command
+func printExchange(out io.Writer, exchange harness.Exchange) {
+	fmt.Fprintf(out, "status %d\n", exchange.Status)
+	if exchange.Acknowledgement != nil {
+		printResponse(out, "acknowledgement", exchange.Acknowledgement)
+	}
+	for i := range exchange.Responses {
+		printResponse(out, "response_url", &exchange.Responses[i])
+	}
+}
+
+func printResponse(out io.Writer, source string, response *slack.SlackResponse) {
+	encoded, _ := json.MarshalIndent(response, "", "  ")
+	fmt.Fprintf(out, "%s:\n%s\n", source, encoded)
+}
@@ -1,27 +1,38 @@
 package main
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"net/netip"
 	"os"
-	"strings"
+	"time"
 
 	viperpit "github.com/ajpauwels/pit-of-vipers"
 	"github.com/pauwels-labs/slack-bot/internal/config"
+	"github.com/pauwels-labs/slack-bot/internal/logging"
 	"github.com/pauwels-labs/slack-bot/pkg/handlers"
 	"github.com/pauwels-labs/slack-bot/pkg/slack"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// How long config changes must settle before they are applied
+	reloadDebounceWindow = 500 * time.Millisecond
+	// How often the Web API's availability is checked
+	webAPIWatchInterval = time.Minute
 )
 
 func main() {
-	// Create structured logger
-	logger, err := zap.NewProduction()
-	if err != nil {
-		log.Fatalf("couldn't initialize structured logger: %v", err)
+	// Simulate Slack against a running bot instead of serving, when asked
+	if len(os.Args) > 1 && os.Args[1] == "harness" {
+		os.Exit(runHarness(os.Args[2:], os.Stdin, os.Stdout))
 	}
-	defer logger.Sync()
 
 	// Load env-specific configuration
 	env := os.Getenv("APPCFG_meta_env")
@@ -33,6 +44,16 @@ func main() {
 		configPath = "/etc/slack-bot/config"
 	}
 
+	// Create structured logger, its level is set from config once loaded
+	// and its format may be overridden by the first config loaded
+	logLevel := zap.NewAtomicLevel()
+	logFormat := logging.DefaultFormat(env)
+	logger, err := logging.New(logLevel, logFormat, zapcore.Lock(os.Stderr))
+	if err != nil {
+		log.Fatalf("couldn't initialize structured logger: %v", err)
+	}
+	defer logger.Sync()
+
 	// Create viper instances for base and env-specific config files
 	baseViper := viper.New()
 	baseViper.AddConfigPath(configPath)
@@ -41,43 +62,299 @@ func main() {
 	envViper.AddConfigPath(configPath)
 	envViper.SetConfigName(env)
 
+	// Coalesce bursts of config changes so that only the latest is
+	// applied, restarting the server once
 	vpCh, errCh := viperpit.New([]*viper.Viper{baseViper, envViper})
+	debouncedVpCh := config.Debounce(vpCh, reloadDebounceWindow)
+	var supervisor *slack.Supervisor
+	var supervisorErrCh <-chan error
+	firstConfig := true
+	readiness := slack.NewReadinessGate()
+	cancellations := slack.NewCancellationRegistry()
+	history := slack.NewCommandHistory(0)
+	// Conversations, and the commands remembered to catch duplicates,
+	// outlive config reloads, which rebuild the bot
+	conversations := slack.NewMemoryConversationStore(0)
+	idempotency := slack.NewIdempotencyCache()
+	retries := slack.NewRetryDeduplicator(0)
+	configProvider := config.NewProvider()
+	stopSocketMode := func() {}
+	stopWebAPIWatch := func() {}
 	for {
 		select {
-		case vp := <-vpCh:
-			// Workaround to add ENV prefix and be able to unmarshal env-provided values
-			vp.SetEnvPrefix("APPCFG")
-			vp.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-			for _, key := range vp.AllKeys() {
-				val := vp.Get(key)
-				vp.Set(key, val)
-			}
-
-			// Unmarshal config into struct
-			var config config.Config
-			vp.Unmarshal(&config)
-
-			logger.Info("config", zap.Uint16("port", config.Port), zap.String("slack.signingkey", string(config.Slack.SigningKey)))
-
-			// Create slack bot server
-			slackBot := slack.NewSlackBot(config.Port, config.Slack.SigningKey, CreateHandlers())
-			logger.Info("starting server", zap.Uint16("port", config.Port))
-			err := slackBot.ListenAndServe(logger)
-
-			// Handle normal shutdown and server start errors
-			if errors.Is(err, http.ErrServerClosed) {
-				logger.Info("server has shutdown normally")
-				break
+		case vp := <-debouncedVpCh:
+			// Unmarshal config into struct, keeping the running config if
+			// the new one is invalid
+			config, err := config.Load(vp)
+			if err != nil && firstConfig {
+				logger.Fatal("invalid config", zap.Error(err))
+			} else if err != nil {
+				logger.Error("invalid config, keeping the running config", zap.Error(err))
+				continue
+			}
+
+			// Parse the source ranges requests may come from, an invalid
+			// range is treated like any other invalid config
+			allowedSourceRanges, err := slack.ParsePrefixes(config.Slack.AllowedSourceRanges)
+			var trustedProxies []netip.Prefix
+			if err == nil {
+				trustedProxies, err = slack.ParsePrefixes(config.Slack.TrustedProxies)
+			}
+			if err != nil && firstConfig {
+				logger.Fatal("invalid source ranges", zap.Error(err))
+			} else if err != nil {
+				logger.Error("invalid source ranges, keeping the running config", zap.Error(err))
+				continue
+			}
+
+			// Route outbound requests through the configured proxy, which
+			// is left unchanged if invalid
+			err = slack.SetProxy(config.Outbound.Proxy)
+			if err != nil && firstConfig {
+				logger.Fatal("invalid outbound proxy", zap.Error(err))
+			} else if err != nil {
+				logger.Error("invalid outbound proxy, keeping the running config", zap.Error(err))
+				continue
+			}
+
+			// Read the signing key and bot token from the configured
+			// secret source, which may be the config itself. The bot keeps
+			// looking up the signing key from the source for each request.
+			secrets, err := CreateSecretSource(config)
+			if err == nil {
+				config.Slack.SigningKey, err = secrets.SigningKey()
+			}
+			if errors.Is(err, slack.ErrNoSigningKey) && len(config.Slack.VerificationToken) > 0 {
+				err = nil
+			}
+			if err == nil {
+				config.Slack.BotToken, err = secrets.BotToken()
+			}
+			if err != nil && firstConfig {
+				logger.Fatal("could not read secrets", zap.Error(err))
+			} else if err != nil {
+				logger.Error("could not read secrets, keeping the running config", zap.Error(err))
+				continue
+			}
+
+			// Features relying on the Web API need a bot token, which the
+			// client asks the secret source for on every call so that
+			// rotated tokens are used right away
+			var webAPIClient slack.SlackAPI
+			if len(config.Slack.BotToken) > 0 {
+				webAPIClient = slack.NewClientWithSecrets(secrets)
+			}
+
+			// Create the handlers, which may have their own config
+			commandHandlers, err := CreateHandlers(config, configProvider, cancellations, history, webAPIClient)
+			if err != nil && firstConfig {
+				logger.Fatal("invalid handler config", zap.Error(err))
+			} else if err != nil {
+				logger.Error("invalid handler config, keeping the running config", zap.Error(err))
+				continue
+			}
+
+			// Validate the config and apply its defaults, from here on it is
+			// the running config seen through the provider
+			defaulted, err := configProvider.Update(config)
+			if err != nil && firstConfig {
+				logger.Fatal("invalid config", zap.Error(err))
+			} else if err != nil {
+				logger.Error("invalid config, keeping the running config", zap.Error(err))
+				continue
+			}
+			for _, key := range defaulted {
+				logger.Info("config value is missing, using its default", zap.String("key", key))
+			}
+			config = configProvider.Config()
+
+			readiness.MarkReady()
+
+			// Identify the bot in outbound requests
+			slack.SetUserAgent(config.Outbound.UserAgent)
+
+			// Apply the configured log level
+			err = logging.SetLevel(logLevel, config.Log.Level)
+			if err != nil {
+				logger.Error("invalid log level", zap.String("level", config.Log.Level), zap.Error(err))
+			}
+
+			logger.Info("config", zap.Uint16("port", config.Port))
+
+			if len(config.Slack.SigningKey) == 0 && len(config.Slack.VerificationToken) > 0 {
+				logger.Warn("verifying requests with the deprecated verification token, configure slack.signingkey instead")
+			} else {
+				slack.WarnOnSuspiciousSigningKey(logger, config.Slack.SigningKey)
+			}
+
+			// Some settings can't change without a restart and are only
+			// applied from the first config loaded
+			if firstConfig {
+				firstConfig = false
+
+				// Config files are optional, the environment alone can
+				// provide the whole config
+				if len(baseViper.ConfigFileUsed()) == 0 {
+					logger.Info("no base config file found, relying on environment variables", zap.String("path", configPath))
+				}
+				if len(envViper.ConfigFileUsed()) == 0 {
+					logger.Info("no env config file found, relying on environment variables", zap.String("path", configPath), zap.String("env", env))
+				}
+
+				// Switch to the configured log format
+				if len(config.Log.Format) > 0 && config.Log.Format != logFormat {
+					formattedLogger, err := logging.New(logLevel, config.Log.Format, zapcore.Lock(os.Stderr))
+					if err != nil {
+						logger.Error("invalid log format", zap.String("format", config.Log.Format), zap.Error(err))
+					} else {
+						logger = formattedLogger
+					}
+				}
+
+				// Start supervising the slack bot server and serve metrics on
+				// their own port
+				supervisor = slack.NewSupervisor(logger)
+				supervisorErrCh = supervisor.Errors()
+				go ServeMetrics(logger, config.Metrics.Port)
+			}
+
+			// Keep undelivered responses in a file if configured, they are
+			// logged otherwise
+			var deadLetters slack.DeadLetterSink
+			if len(config.DeadLetter.File) > 0 {
+				deadLetters = slack.NewFileDeadLetterSink(config.DeadLetter.File)
+			}
+
+			// Watch the Web API so that an outage, even at boot, degrades
+			// the features relying on it rather than stopping the bot
+			stopWebAPIWatch()
+			stopWebAPIWatch = func() {}
+			if webAPIClient != nil {
+				var ctx context.Context
+				ctx, stopWebAPIWatch = context.WithCancel(context.Background())
+				go slack.WatchWebAPI(ctx, logger, webAPIClient, readiness, webAPIWatchInterval)
 			} else {
-				logger.Fatal("failed to start http server", zap.Error(err))
+				readiness.MarkRecovered("web_api")
+			}
+
+			// Commands behave the same whether they arrive over HTTP or
+			// Socket Mode
+			commandOptions := []slack.SlackBotOption{
+				slack.WithCaseSensitiveCommands(config.Slack.CaseSensitiveCommands),
+				slack.WithHelpCommandName(config.Slack.HelpCommand),
+				slack.WithCancellationRegistry(cancellations),
+				slack.WithCommandHistory(history),
+				slack.WithConversationStore(conversations),
+				slack.WithIdempotencyCache(idempotency),
+				slack.WithFeatureFlags(slack.StaticFeatureFlags(config.Features)),
+				slack.WithWebAPIClient(webAPIClient),
+				slack.WithDeadLetterSink(deadLetters),
+				slack.WithResponseDecoration(config.Slack.ResponsePrefix, config.Slack.ResponseSuffix),
+				slack.WithHiddenCommandsShown(config.Slack.ShowHiddenCommands),
+				slack.WithHelpAdmins(config.Slack.HelpAdmins...),
+				slack.WithHelpMessageLimit(config.Slack.HelpMessageLimit),
+				slack.WithArgumentLimits(config.Slack.MaxArguments, config.Slack.MaxTextLength),
+				slack.WithNamespaceSeparator(config.Slack.NamespaceSeparator),
+				slack.WithLogger(logger),
 			}
+
+			// Create slack bot server and swap it in for the running one,
+			// draining any in-flight requests first
+			slackBot := slack.NewSlackBot(
+				config.Port,
+				secrets,
+				commandHandlers,
+				append([]slack.SlackBotOption{
+					slack.WithReadinessGate(readiness),
+					slack.WithRetryDeduplicator(retries),
+					slack.WithAllowedSourceRanges(allowedSourceRanges),
+					slack.WithTrustedProxies(trustedProxies),
+					slack.WithUnixSocket(config.Listen.Socket),
+					slack.WithRequestTimeout(config.RequestTimeout),
+					slack.WithLegacyVerificationToken(config.Slack.VerificationToken),
+				}, commandOptions...)...,
+			)
+			supervisor.Apply(slackBot, config.DrainTimeout)
+
+			// Replace the socket mode connection, if enabled
+			stopSocketMode()
+			stopSocketMode = func() {}
+			if config.Slack.SocketMode {
+				var ctx context.Context
+				ctx, stopSocketMode = context.WithCancel(context.Background())
+				socketModeServer := slack.NewSocketModeServer(
+					config.Slack.AppToken,
+					append([]slack.SlackSlashCommandHandler{}, commandHandlers...),
+					commandOptions...,
+				)
+				go func() {
+					err := socketModeServer.Run(ctx, logger)
+					logger.Error("socket mode connection has stopped", zap.Error(err))
+				}()
+			}
+		case err := <-supervisorErrCh:
+			logger.Fatal("failed to start http server", zap.Error(err))
 		case err := <-errCh:
 			logger.Error("error loading config", zap.Error(err))
 		}
 	}
 }
 
-func CreateHandlers() []slack.SlackSlashCommandHandler {
-	echoHandler := handlers.NewEchoHandler()
-	return []slack.SlackSlashCommandHandler{echoHandler}
+func CreateHandlers(cfg config.Config, provider config.ConfigProvider, cancellations *slack.CancellationRegistry, history *slack.CommandHistory, client slack.SlackAPI) ([]slack.SlackSlashCommandHandler, error) {
+	// Echo posts user input back to the channel, so mentions and links in
+	// it are neutralized first
+	echoHandler := slack.NewSanitizingHandler(handlers.NewEchoHandler(), slack.DefaultSanitizeOptions)
+	whoAmIHandler := handlers.NewWhoAmIHandler()
+	cancelHandler := slack.NewCancelHandler(cancellations)
+	historyHandler := slack.NewHistoryHandler(history)
+	commandHandlers := []slack.SlackSlashCommandHandler{echoHandler, whoAmIHandler, cancelHandler, historyHandler}
+
+	// Handlers calling the Web API are only available with a bot token
+	if client != nil {
+		var remindConfig handlers.RemindConfig
+		err := cfg.HandlerConfig("remind", &remindConfig)
+		if err != nil {
+			return nil, err
+		}
+		commandHandlers = append(commandHandlers, handlers.NewRemindHandler(client, remindConfig))
+	}
+
+	// Let admins see the running config, and which commands it enables
+	var configHandlerConfig handlers.ConfigHandlerConfig
+	err := cfg.HandlerConfig("config", &configHandlerConfig)
+	if err != nil {
+		return nil, err
+	}
+	commands := []string{}
+	for _, handler := range slack.EnabledHandlers(commandHandlers, slack.StaticFeatureFlags(cfg.Features)) {
+		commands = append(commands, handler.CommandName())
+	}
+	configHandler := handlers.NewConfigHandler(provider, append(commands, "config"), configHandlerConfig)
+	commandHandlers = append(commandHandlers, configHandler)
+
+	return commandHandlers, nil
+}
+
+func CreateSecretSource(cfg config.Config) (slack.SecretSource, error) {
+	switch cfg.Secrets.Source {
+	case "", "config":
+		return slack.NewStaticSecretSource(cfg.Slack.SigningKey, cfg.Slack.BotToken), nil
+	case "file":
+		return slack.NewFileSecretSource(cfg.Secrets.File.SigningKey, cfg.Secrets.File.BotToken), nil
+	case "env":
+		return slack.NewEnvSecretSource(cfg.Secrets.Env.SigningKey, cfg.Secrets.Env.BotToken), nil
+	case "vault":
+		vault := cfg.Secrets.Vault
+		return slack.NewVaultSecretSource(vault.Address, vault.Token, vault.Path, vault.RefreshInterval), nil
+	default:
+		return nil, fmt.Errorf("unknown secret source %q", cfg.Secrets.Source)
+	}
+}
+
+func ServeMetrics(logger *zap.Logger, port uint16) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	logger.Info("starting metrics server", zap.Uint16("port", port))
+	err := http.ListenAndServe(fmt.Sprintf(":%d", port), mux)
+	logger.Error("metrics server has stopped", zap.Error(err))
 }
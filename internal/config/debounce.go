@@ -0,0 +1,48 @@
+package config
+
+import (
+	"time"
+)
+
+// Debounce forwards the latest value received from updates once no other
+// value has arrived for window, so that a burst of config changes, such
+// as a config map being updated several times, is applied only once. The
+// returned channel is closed after updates is closed and any pending
+// value has been forwarded.
+func Debounce[T any](updates <-chan T, window time.Duration) <-chan T {
+	debounced := make(chan T)
+	go func() {
+		defer close(debounced)
+
+		var latest T
+		pending := false
+		timer := time.NewTimer(window)
+		timer.Stop()
+		for {
+			select {
+			case update, ok := <-updates:
+				if !ok {
+					if pending {
+						debounced <- latest
+					}
+					return
+				}
+
+				// Restart the window with every update
+				latest, pending = update, true
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(window)
+			case <-timer.C:
+				debounced <- latest
+				pending = false
+			}
+		}
+	}()
+
+	return debounced
+}
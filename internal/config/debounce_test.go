@@ -0,0 +1,39 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDebounceAppliesOnlyTheFinalUpdate(t *testing.T) {
+	updates := make(chan int)
+	debounced := Debounce(updates, 50*time.Millisecond)
+
+	for i := 1; i <= 3; i++ {
+		updates <- i
+	}
+
+	select {
+	case update := <-debounced:
+		if update != 3 {
+			t.Errorf("expected the final update, got %d", update)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no update was forwarded")
+	}
+	select {
+	case update := <-debounced:
+		t.Errorf("expected a single update, got another with %d", update)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Later updates are forwarded in their own window
+	updates <- 4
+	close(updates)
+	if update := <-debounced; update != 4 {
+		t.Errorf("expected the next update, got %d", update)
+	}
+	if _, ok := <-debounced; ok {
+		t.Error("expected the channel to close after updates")
+	}
+}
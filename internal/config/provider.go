@@ -0,0 +1,107 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// ConfigProvider gives access to the running config, so that code using
+// it isn't tied to the layout of Config, can be given a fake config in
+// tests, and sees a new config as soon as it is reloaded
+type ConfigProvider interface {
+	// Config returns the whole running config
+	Config() Config
+	Port() uint16
+	DrainTimeout() time.Duration
+	RequestTimeout() time.Duration
+	Slack() SlackConfig
+	LogLevel() string
+	// HandlerConfig decodes the settings of the named handler, like
+	// Config.HandlerConfig
+	HandlerConfig(name string, target interface{}) error
+}
+
+// Provider is a ConfigProvider whose config is replaced on reloads
+type Provider struct {
+	current atomic.Pointer[Config]
+}
+
+// NewProvider creates a provider serving an empty config until the first
+// one is set with Update
+func NewProvider() *Provider {
+	p := &Provider{}
+	p.current.Store(&Config{})
+
+	return p
+}
+
+// Update validates cfg and applies its defaults before making it the
+// running config, returning the keys of the settings that were
+// defaulted. An invalid config leaves the running config unchanged.
+func (p *Provider) Update(cfg Config) ([]string, error) {
+	err := cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+	defaulted := cfg.ApplyDefaults()
+	p.current.Store(&cfg)
+
+	return defaulted, nil
+}
+
+func (p *Provider) Config() Config {
+	return *p.current.Load()
+}
+
+func (p *Provider) Port() uint16 {
+	return p.current.Load().Port
+}
+
+func (p *Provider) DrainTimeout() time.Duration {
+	return p.current.Load().DrainTimeout
+}
+
+func (p *Provider) RequestTimeout() time.Duration {
+	return p.current.Load().RequestTimeout
+}
+
+func (p *Provider) Slack() SlackConfig {
+	return p.current.Load().Slack
+}
+
+func (p *Provider) LogLevel() string {
+	return p.current.Load().Log.Level
+}
+
+func (p *Provider) HandlerConfig(name string, target interface{}) error {
+	return p.current.Load().HandlerConfig(name, target)
+}
+
+// Validate reports settings that can't be used
+func (c Config) Validate() error {
+	errs := []error{}
+	if c.DrainTimeout < 0 {
+		errs = append(errs, fmt.Errorf("draintimeout must not be negative, got %s", c.DrainTimeout))
+	}
+	if c.RequestTimeout < 0 {
+		errs = append(errs, fmt.Errorf("requesttimeout must not be negative, got %s", c.RequestTimeout))
+	}
+	if c.Slack.HelpMessageLimit < 0 {
+		errs = append(errs, fmt.Errorf("slack.helpmessagelimit must not be negative, got %d", c.Slack.HelpMessageLimit))
+	}
+	if c.Slack.MaxArguments < 0 {
+		errs = append(errs, fmt.Errorf("slack.maxarguments must not be negative, got %d", c.Slack.MaxArguments))
+	}
+	if c.Slack.MaxTextLength < 0 {
+		errs = append(errs, fmt.Errorf("slack.maxtextlength must not be negative, got %d", c.Slack.MaxTextLength))
+	}
+	switch c.Secrets.Source {
+	case "", "config", "file", "env", "vault":
+	default:
+		errs = append(errs, fmt.Errorf("unknown secret source %q", c.Secrets.Source))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProviderServesTheLatestValidConfig(t *testing.T) {
+	provider := NewProvider()
+
+	defaulted, err := provider.Update(Config{DrainTimeout: 10 * time.Second, Log: LogConfig{Level: "debug"}})
+	if err != nil {
+		t.Fatalf("could not update the config: %v", err)
+	}
+	if provider.Port() != DefaultPort || len(defaulted) != 2 {
+		t.Errorf("expected defaults to be applied, got port %d and defaulted %v", provider.Port(), defaulted)
+	}
+	if provider.DrainTimeout() != 10*time.Second || provider.LogLevel() != "debug" {
+		t.Errorf("unexpected config %+v", provider.Config())
+	}
+
+	// An invalid config is rejected and the previous one kept
+	_, err = provider.Update(Config{Port: 9000, Secrets: SecretsConfig{Source: "keychain"}})
+	if err == nil {
+		t.Errorf("expected an unknown secret source to be rejected")
+	}
+	if provider.Port() != DefaultPort {
+		t.Errorf("expected the previous config to be kept, got port %d", provider.Port())
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		config Config
+		valid  bool
+	}{
+		{Config{}, true},
+		{Config{Secrets: SecretsConfig{Source: "vault"}}, true},
+		{Config{DrainTimeout: -time.Second}, false},
+		{Config{RequestTimeout: -time.Second}, false},
+		{Config{Slack: SlackConfig{HelpMessageLimit: -1}}, false},
+		{Config{Secrets: SecretsConfig{Source: "keychain"}}, false},
+	}
+
+	for _, test := range tests {
+		err := test.config.Validate()
+		if (err == nil) != test.valid {
+			t.Errorf("expected valid to be %t for %+v, got %v", test.valid, test.config, err)
+		}
+	}
+}
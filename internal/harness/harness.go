@@ -0,0 +1,227 @@
+package harness
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pauwels-labs/slack-bot/pkg/slack"
+)
+
+// Fixture is a slash command to send to the bot, as read from a fixture
+// file. Fields left empty are given defaults by the harness.
+type Fixture struct {
+	Command   string `json:"command"`
+	Text      string `json:"text"`
+	UserID    string `json:"user_id"`
+	UserName  string `json:"user_name"`
+	ChannelID string `json:"channel_id"`
+	TeamID    string `json:"team_id"`
+}
+
+// Exchange is what the bot answered to a command: the status and
+// acknowledgement of the request itself, followed by every response
+// posted to its response_url
+type Exchange struct {
+	Status          int
+	Acknowledgement *slack.SlackResponse
+	Responses       []slack.SlackResponse
+}
+
+// Harness simulates Slack locally, sending signed slash commands to a
+// running bot and capturing what it posts to their response_url, so that
+// handlers can be tried without exposing the bot to Slack
+type Harness struct {
+	target     string
+	signingKey string
+	defaults   Fixture
+	settle     time.Duration
+	listener   net.Listener
+	server     *http.Server
+
+	lock      sync.Mutex
+	sequence  int
+	responses map[string]chan slack.SlackResponse
+}
+
+// New starts a harness sending commands to the bot at target, signed
+// with signingKey. Fields missing from the commands sent are taken from
+// defaults. Responses are collected until none has arrived for settle.
+func New(target string, signingKey string, defaults Fixture, settle time.Duration) (*Harness, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("could not listen for responses: %w", err)
+	}
+
+	h := &Harness{
+		target:     target,
+		signingKey: signingKey,
+		defaults:   defaults,
+		settle:     settle,
+		listener:   listener,
+		responses:  map[string]chan slack.SlackResponse{},
+	}
+	h.server = &http.Server{Handler: http.HandlerFunc(h.captureResponse)}
+	go h.server.Serve(listener)
+
+	return h, nil
+}
+
+// Close stops capturing responses
+func (h *Harness) Close() error {
+	return h.server.Close()
+}
+
+// captureResponse records a response posted to one of the response_urls
+// handed out by the harness
+func (h *Harness) captureResponse(w http.ResponseWriter, r *http.Request) {
+	h.lock.Lock()
+	responses, ok := h.responses[r.URL.Path]
+	h.lock.Unlock()
+	if !ok {
+		http.Error(w, "expired_url", http.StatusNotFound)
+		return
+	}
+
+	var response slack.SlackResponse
+	err := json.NewDecoder(r.Body).Decode(&response)
+	if err != nil {
+		http.Error(w, "invalid_payload", http.StatusBadRequest)
+		return
+	}
+	responses <- response
+	w.Write([]byte("ok"))
+}
+
+// Send posts the command to the bot as Slack would and collects its
+// answer
+func (h *Harness) Send(ctx context.Context, fixture Fixture) (Exchange, error) {
+	responseURL, responses, release := h.newResponseURL()
+	defer release()
+
+	form := url.Values{
+		"command":      {withDefault(fixture.Command, h.defaults.Command)},
+		"text":         {fixture.Text},
+		"user_id":      {withDefault(fixture.UserID, h.defaults.UserID)},
+		"user_name":    {withDefault(fixture.UserName, h.defaults.UserName)},
+		"channel_id":   {withDefault(fixture.ChannelID, h.defaults.ChannelID)},
+		"team_id":      {withDefault(fixture.TeamID, h.defaults.TeamID)},
+		"trigger_id":   {fmt.Sprintf("harness.%d", time.Now().UnixNano())},
+		"response_url": {responseURL},
+	}
+	body := form.Encode()
+	header := http.Header{}
+	header.Set("content-type", "application/x-www-form-urlencoded")
+	sign(header, h.signingKey, body, time.Now())
+
+	return h.post(ctx, body, header, responses)
+}
+
+// newResponseURL hands out a response_url of its own to a command, so
+// that responses can't be mixed up between commands, until it is
+// released
+func (h *Harness) newResponseURL() (string, chan slack.SlackResponse, func()) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.sequence++
+	path := fmt.Sprintf("/responses/%d", h.sequence)
+	responses := make(chan slack.SlackResponse, 16)
+	h.responses[path] = responses
+
+	return fmt.Sprintf("http://%s%s", h.listener.Addr(), path), responses, func() {
+		h.lock.Lock()
+		defer h.lock.Unlock()
+		delete(h.responses, path)
+	}
+}
+
+// post sends the body to the bot and collects its answer, along with the
+// responses posted to the command's response_url when it is captured
+func (h *Harness) post(ctx context.Context, body string, header http.Header, responses chan slack.SlackResponse) (Exchange, error) {
+	request, err := http.NewRequestWithContext(ctx, "POST", h.target, strings.NewReader(body))
+	if err != nil {
+		return Exchange{}, err
+	}
+	request.Header = header
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return Exchange{}, fmt.Errorf("could not reach the bot: %w", err)
+	}
+	defer response.Body.Close()
+
+	// Read the acknowledgement, if the bot sent one
+	exchange := Exchange{Status: response.StatusCode}
+	acknowledgement, err := io.ReadAll(response.Body)
+	if err != nil {
+		return exchange, fmt.Errorf("could not read the bot's answer: %w", err)
+	}
+	if len(bytes.TrimSpace(acknowledgement)) > 0 {
+		var ack slack.SlackResponse
+		if json.Unmarshal(acknowledgement, &ack) == nil {
+			exchange.Acknowledgement = &ack
+		}
+	}
+	if responses == nil {
+		return exchange, nil
+	}
+
+	// Collect responses until they settle
+	for {
+		select {
+		case response := <-responses:
+			exchange.Responses = append(exchange.Responses, response)
+		case <-time.After(h.settle):
+			return exchange, nil
+		case <-ctx.Done():
+			return exchange, ctx.Err()
+		}
+	}
+}
+
+// sign adds the headers Slack signs its requests with
+func sign(header http.Header, signingKey string, body string, at time.Time) {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(signingKey))
+	mac.Write([]byte(fmt.Sprintf("v0:%s:%s", timestamp, body)))
+
+	header.Set(slack.TimestampHeaderName, timestamp)
+	header.Set(slack.SignatureHeaderName, "v0="+hex.EncodeToString(mac.Sum(nil)))
+}
+
+func withDefault(value string, defaultValue string) string {
+	if len(value) == 0 {
+		return defaultValue
+	}
+
+	return value
+}
+
+// ReadFixtures reads a JSON array of commands from path
+func ReadFixtures(path string) ([]Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fixtures []Fixture
+	err = json.Unmarshal(data, &fixtures)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode fixtures in %s: %w", path, err)
+	}
+
+	return fixtures, nil
+}
@@ -0,0 +1,87 @@
+package harness
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pauwels-labs/slack-bot/pkg/handlers"
+	"github.com/pauwels-labs/slack-bot/pkg/slack"
+	"go.uber.org/zap"
+)
+
+func newBot(t *testing.T, signingKey string) *httptest.Server {
+	bot := httptest.NewServer(http.HandlerFunc(slack.BuildHandler(zap.NewNop(), slack.NewStaticSecretSource(signingKey, ""), []slack.SlackSlashCommandHandler{handlers.NewEchoHandler()})))
+	t.Cleanup(bot.Close)
+
+	return bot
+}
+
+func TestHarnessCapturesResponses(t *testing.T) {
+	bot := newBot(t, "abc")
+	h, err := New(bot.URL, "abc", Fixture{Command: "/bot", UserID: "U1"}, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("could not start the harness: %v", err)
+	}
+	defer h.Close()
+
+	exchange, err := h.Send(context.Background(), Fixture{Text: "echo --upper hello"})
+	if err != nil {
+		t.Fatalf("could not send the command: %v", err)
+	}
+	if exchange.Status != http.StatusOK {
+		t.Errorf("expected the command to be acknowledged, got status %d", exchange.Status)
+	}
+	if len(exchange.Responses) != 1 || exchange.Responses[0].Text != "HELLO" {
+		t.Errorf("expected the echo response to be captured, got %+v", exchange.Responses)
+	}
+}
+
+func TestHarnessSendsFixtures(t *testing.T) {
+	bot := newBot(t, "abc")
+	path := filepath.Join(t.TempDir(), "fixtures.json")
+	err := os.WriteFile(path, []byte(`[{"text": "echo one"}, {"text": "echo two", "user_id": "U2"}]`), 0o600)
+	if err != nil {
+		t.Fatalf("could not write fixtures: %v", err)
+	}
+	fixtures, err := ReadFixtures(path)
+	if err != nil {
+		t.Fatalf("could not read fixtures: %v", err)
+	}
+
+	h, err := New(bot.URL, "abc", Fixture{Command: "/bot"}, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("could not start the harness: %v", err)
+	}
+	defer h.Close()
+	for i, expected := range []string{"one", "two"} {
+		exchange, err := h.Send(context.Background(), fixtures[i])
+		if err != nil {
+			t.Fatalf("could not send fixture %d: %v", i, err)
+		}
+		if len(exchange.Responses) != 1 || exchange.Responses[0].Text != expected {
+			t.Errorf("unexpected responses to fixture %d: %+v", i, exchange.Responses)
+		}
+	}
+}
+
+func TestHarnessWithTheWrongSigningKeyGetsNoResponse(t *testing.T) {
+	bot := newBot(t, "abc")
+	h, err := New(bot.URL, "def", Fixture{Command: "/bot"}, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("could not start the harness: %v", err)
+	}
+	defer h.Close()
+
+	exchange, err := h.Send(context.Background(), Fixture{Text: "echo hello"})
+	if err != nil {
+		t.Fatalf("could not send the command: %v", err)
+	}
+	if len(exchange.Responses) != 0 {
+		t.Errorf("expected an unverified command not to be answered, got %+v", exchange.Responses)
+	}
+}
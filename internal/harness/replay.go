@@ -0,0 +1,70 @@
+package harness
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// CapturedRequest is a raw request Slack sent to the bot, as saved when
+// debugging an issue
+type CapturedRequest struct {
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+}
+
+// ReadCapturedRequest reads a captured request saved as JSON at path
+func ReadCapturedRequest(path string) (CapturedRequest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return CapturedRequest{}, err
+	}
+
+	var captured CapturedRequest
+	err = json.Unmarshal(data, &captured)
+	if err != nil {
+		return CapturedRequest{}, fmt.Errorf("could not decode captured request in %s: %w", path, err)
+	}
+
+	return captured, nil
+}
+
+// ReplayRequest sends the request captured in the fixture at path to the
+// bot again, to reproduce a bug. When the harness has a signing key, the
+// request's response_url is replaced so that responses are captured, and
+// the request is signed again. Otherwise, it is sent exactly as captured
+// with its original signature, which the bot only accepts within five
+// minutes of the capture, and responses go to the original response_url.
+func (h *Harness) ReplayRequest(ctx context.Context, path string) (Exchange, error) {
+	captured, err := ReadCapturedRequest(path)
+	if err != nil {
+		return Exchange{}, err
+	}
+
+	header := http.Header{}
+	for name, value := range captured.Headers {
+		header.Set(name, value)
+	}
+	if len(header.Get("content-type")) == 0 {
+		header.Set("content-type", "application/x-www-form-urlencoded")
+	}
+	if len(h.signingKey) == 0 {
+		return h.post(ctx, captured.Body, header, nil)
+	}
+
+	form, err := url.ParseQuery(captured.Body)
+	if err != nil {
+		return Exchange{}, fmt.Errorf("could not parse the captured body: %w", err)
+	}
+	responseURL, responses, release := h.newResponseURL()
+	defer release()
+	form.Set("response_url", responseURL)
+	body := form.Encode()
+	sign(header, h.signingKey, body, time.Now())
+
+	return h.post(ctx, body, header, responses)
+}
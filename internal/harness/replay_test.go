@@ -0,0 +1,59 @@
+package harness
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeCapturedRequest(t *testing.T, captured CapturedRequest) string {
+	data, err := json.Marshal(captured)
+	if err != nil {
+		t.Fatalf("could not encode captured request: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "request.json")
+	err = os.WriteFile(path, data, 0o600)
+	if err != nil {
+		t.Fatalf("could not write captured request: %v", err)
+	}
+
+	return path
+}
+
+func TestReplayRequestResignsCapturedRequests(t *testing.T) {
+	bot := newBot(t, "abc")
+
+	// A request captured in production, signed with another key long ago
+	// and pointing at Slack's response_url
+	path := writeCapturedRequest(t, CapturedRequest{
+		Headers: map[string]string{
+			"Content-Type":              "application/x-www-form-urlencoded",
+			"X-Slack-Request-Timestamp": "1700000000",
+			"X-Slack-Signature":         "v0=0000",
+		},
+		Body: url.Values{
+			"command":      {"/bot"},
+			"text":         {"echo --reverse hello"},
+			"user_id":      {"U1"},
+			"response_url": {"https://hooks.slack.com/commands/T1/1/expired"},
+		}.Encode(),
+	})
+
+	h, err := New(bot.URL, "abc", Fixture{}, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("could not start the harness: %v", err)
+	}
+	defer h.Close()
+	exchange, err := h.ReplayRequest(context.Background(), path)
+	if err != nil {
+		t.Fatalf("could not replay the request: %v", err)
+	}
+
+	if len(exchange.Responses) != 1 || exchange.Responses[0].Text != "olleh" {
+		t.Errorf("expected the replayed command's response, got %+v", exchange.Responses)
+	}
+}
@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+// DefaultFormat returns the log format used for an environment when none
+// is configured, console output being easier to read during development
+func DefaultFormat(env string) string {
+	if env == "local" {
+		return FormatConsole
+	}
+
+	return FormatJSON
+}
+
+// New creates a logger equivalent to zap.NewProduction() writing to the
+// given output in the given format, whose level can be changed at runtime
+// through level
+func New(level zap.AtomicLevel, format string, output zapcore.WriteSyncer) (*zap.Logger, error) {
+	var encoder zapcore.Encoder
+	switch format {
+	case FormatJSON:
+		encoder = zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	case FormatConsole:
+		encoderConfig := zap.NewDevelopmentEncoderConfig()
+		encoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	default:
+		return nil, fmt.Errorf("unknown log format %q, expected %s or %s", format, FormatJSON, FormatConsole)
+	}
+	core := zapcore.NewCore(encoder, output, level)
+	core = zapcore.NewSamplerWithOptions(core, time.Second, 100, 100)
+
+	return zap.New(core, zap.AddCaller(), zap.AddStacktrace(zap.ErrorLevel)), nil
+}
+
+// SetLevel parses a level name such as `debug` or `info` and applies it,
+// leaving the level untouched if the name is invalid. An empty name
+// resets the level to info.
+func SetLevel(level zap.AtomicLevel, name string) error {
+	if len(name) == 0 {
+		name = "info"
+	}
+
+	parsed, err := zapcore.ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	level.SetLevel(parsed)
+
+	return nil
+}
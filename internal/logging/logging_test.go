@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLevelControlsDebugLogs(t *testing.T) {
+	var output bytes.Buffer
+	level := zap.NewAtomicLevel()
+	logger, err := New(level, FormatJSON, zapcore.AddSync(&output))
+	if err != nil {
+		t.Fatalf("could not create logger: %v", err)
+	}
+
+	err = SetLevel(level, "info")
+	if err != nil {
+		t.Fatalf("could not set level: %v", err)
+	}
+	logger.Debug("hidden")
+	if strings.Contains(output.String(), "hidden") {
+		t.Errorf("debug log was emitted at info level")
+	}
+
+	err = SetLevel(level, "debug")
+	if err != nil {
+		t.Fatalf("could not set level: %v", err)
+	}
+	logger.Debug("shown")
+	if !strings.Contains(output.String(), "shown") {
+		t.Errorf("debug log was suppressed at debug level")
+	}
+}
+
+func TestSetLevelRejectsUnknownLevel(t *testing.T) {
+	level := zap.NewAtomicLevelAt(zap.WarnLevel)
+	if SetLevel(level, "loud") == nil {
+		t.Errorf("expected an error for an unknown level")
+	}
+	if level.Level() != zap.WarnLevel {
+		t.Errorf("level was changed to %s by an invalid name", level.Level())
+	}
+}
+
+func TestDefaultFormatByEnv(t *testing.T) {
+	if format := DefaultFormat("local"); format != FormatConsole {
+		t.Errorf("expected console logs for the local env, got %s", format)
+	}
+	if format := DefaultFormat("prod"); format != FormatJSON {
+		t.Errorf("expected json logs for the prod env, got %s", format)
+	}
+}
+
+func TestFormatSelectsEncoder(t *testing.T) {
+	for _, format := range []string{FormatJSON, FormatConsole} {
+		var output bytes.Buffer
+		logger, err := New(zap.NewAtomicLevel(), format, zapcore.AddSync(&output))
+		if err != nil {
+			t.Fatalf("could not create %s logger: %v", format, err)
+		}
+		logger.Info("hello")
+
+		isJSON := json.Valid(bytes.TrimSpace(output.Bytes()))
+		if isJSON != (format == FormatJSON) {
+			t.Errorf("%s logger wrote %q", format, output.String())
+		}
+	}
+
+	_, err := New(zap.NewAtomicLevel(), "xml", zapcore.AddSync(&bytes.Buffer{}))
+	if err == nil {
+		t.Errorf("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pauwels-labs/slack-bot/internal/config"
+	"github.com/pauwels-labs/slack-bot/pkg/slack"
+)
+
+// ConfigHandlerConfig is read from handlers.config in the bot's config
+type ConfigHandlerConfig struct {
+	// Admins are the IDs of the users allowed to see the config, nobody
+	// is when empty
+	Admins []string `mapstructure:"admins"`
+}
+
+type ConfigHandler struct {
+	provider config.ConfigProvider
+	commands []string
+	admins   map[string]bool
+}
+
+// NewConfigHandler shows the running config from provider, along with
+// the commands enabled by it
+func NewConfigHandler(provider config.ConfigProvider, commands []string, handlerConfig ConfigHandlerConfig) slack.SlackSlashCommandHandler {
+	admins := map[string]bool{}
+	for _, admin := range handlerConfig.Admins {
+		admins[admin] = true
+	}
+
+	return ConfigHandler{provider, commands, admins}
+}
+
+func (a ConfigHandler) Handle(arguments []string, request slack.SlackSlashCommandBody) (*slack.SlackResponse, error) {
+	if !a.admins[request.UserID] {
+		return nil, errors.New("only admins can see the bot's config")
+	}
+
+	// Align the values of every setting, with secrets masked
+	settings := a.provider.Config().Redacted().Settings()
+	settings = append(settings, config.Setting{Key: "commands", Value: strings.Join(a.commands, ", ")})
+	width := 0
+	for _, setting := range settings {
+		if len(setting.Key) > width {
+			width = len(setting.Key)
+		}
+	}
+	lines := []string{}
+	for _, setting := range settings {
+		lines = append(lines, fmt.Sprintf("%-*s %s", width+1, setting.Key+":", setting.Value))
+	}
+
+	return &slack.SlackResponse{
+		ResponseType: "ephemeral",
+		Text:         "```\n" + strings.Join(lines, "\n") + "\n```",
+	}, nil
+}
+
+// Hidden keeps the command out of the list of commands, since only
+// admins can use it
+func (a ConfigHandler) Hidden() bool {
+	return true
+}
+
+func (a ConfigHandler) CommandName() string {
+	return "config"
+}
+
+func (a ConfigHandler) CommandArguments() string {
+	return ""
+}
+
+func (a ConfigHandler) CommandDescription() string {
+	return "Displays the running config, with secrets redacted, for admins"
+}
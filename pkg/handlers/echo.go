@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"fmt"
 	"github.com/pauwels-labs/slack-bot/pkg/slack"
 	"strings"
 )
@@ -13,9 +14,30 @@ func NewEchoHandler() slack.SlackSlashCommandHandler {
 }
 
 func (a EchoHandler) Handle(arguments []string, request slack.SlackSlashCommandBody) (*slack.SlackResponse, error) {
+	flags, words, err := slack.ParseFlags(arguments, "upper", "lower", "reverse")
+	if err != nil {
+		return nil, fmt.Errorf("%w\n%s", err, slack.UsageString(a))
+	}
+
+	text := strings.Join(words, " ")
+	if _, ok := flags["reverse"]; ok {
+		text = reverse(text)
+	}
+	_, upper := flags["upper"]
+	_, lower := flags["lower"]
+	if upper && lower {
+		return nil, fmt.Errorf("--upper and --lower cannot be combined\n%s", slack.UsageString(a))
+	}
+	if upper {
+		text = strings.ToUpper(text)
+	}
+	if lower {
+		text = strings.ToLower(text)
+	}
+
 	return &slack.SlackResponse{
 		ResponseType: "in_channel",
-		Text:         strings.Join(arguments, " "),
+		Text:         text,
 	}, nil
 }
 
@@ -24,9 +46,18 @@ func (a EchoHandler) CommandName() string {
 }
 
 func (a EchoHandler) CommandArguments() string {
-	return "[words...]"
+	return "[--upper|--lower] [--reverse] [words...]"
 }
 
 func (a EchoHandler) CommandDescription() string {
-	return "Accepts any number of arguments and echoes them back to the channel"
+	return "Accepts any number of arguments and echoes them back to the channel, optionally transformed"
+}
+
+func reverse(text string) string {
+	runes := []rune(text)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+
+	return string(runes)
 }
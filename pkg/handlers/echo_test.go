@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pauwels-labs/slack-bot/pkg/slack"
+)
+
+func TestEchoTransforms(t *testing.T) {
+	tests := []struct {
+		arguments []string
+		expected  string
+	}{
+		{[]string{"hello", "world"}, "hello world"},
+		{[]string{"--upper", "hello"}, "HELLO"},
+		{[]string{"--lower", "HeLLo"}, "hello"},
+		{[]string{"--reverse", "hello"}, "olleh"},
+		{[]string{"--reverse", "--upper", "hello"}, "OLLEH"},
+		{[]string{"--", "--upper"}, "--upper"},
+	}
+
+	for _, test := range tests {
+		response, err := NewEchoHandler().Handle(test.arguments, slack.SlackSlashCommandBody{})
+		if err != nil {
+			t.Errorf("echo %v returned an error: %v", test.arguments, err)
+			continue
+		}
+		if response.Text != test.expected {
+			t.Errorf("echo %v returned %q, expected %q", test.arguments, response.Text, test.expected)
+		}
+	}
+}
+
+func TestEchoUnknownFlag(t *testing.T) {
+	_, err := NewEchoHandler().Handle([]string{"--shout", "hello"}, slack.SlackSlashCommandBody{})
+	if err == nil || err.Error() != "unknown flag --shout\nUsage: echo [--upper|--lower] [--reverse] [words...]" {
+		t.Errorf("expected an unknown flag error, got %v", err)
+	}
+}
+
+func TestEchoConflictingFlags(t *testing.T) {
+	_, err := NewEchoHandler().Handle([]string{"--upper", "--lower", "hello"}, slack.SlackSlashCommandBody{})
+	if err == nil {
+		t.Errorf("expected an error when combining --upper and --lower")
+	}
+}
+
+func TestEchoUsageString(t *testing.T) {
+	expected := "Usage: echo [--upper|--lower] [--reverse] [words...]"
+	if usage := slack.UsageString(NewEchoHandler()); usage != expected {
+		t.Errorf("expected %q, got %q", expected, usage)
+	}
+}
+
+func TestEchoThroughBot(t *testing.T) {
+	bot := slack.NewSlackBot(0, slack.NewStaticSecretSource("", ""), []slack.SlackSlashCommandHandler{NewEchoHandler()})
+	response, err := bot.InvokeCommand(context.Background(), "echo --upper hello world", slack.SlackSlashCommandBody{Command: "/bot"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.ResponseType != "in_channel" || response.Text != "HELLO WORLD" {
+		t.Errorf("unexpected response %q as %s", response.Text, response.ResponseType)
+	}
+}
+
+func TestSanitizedEchoNeutralizesBroadcasts(t *testing.T) {
+	handler := slack.NewSanitizingHandler(NewEchoHandler(), slack.DefaultSanitizeOptions)
+
+	// Arguments as Slack sends them, with &, < and > already escaped
+	response, err := handler.Handle([]string{"<!channel>", "R&amp;D", "deploy", "&lt;b&gt;done&lt;/b&gt;", "<@U123|bob>"}, slack.SlackSlashCommandBody{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "@\u200bchannel R&amp;D deploy &lt;b&gt;done&lt;/b&gt; <@U123|bob>"; response.Text != expected {
+		t.Errorf("expected %q, got %q", expected, response.Text)
+	}
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/pauwels-labs/slack-bot/pkg/slack"
+	"strings"
+	"time"
+)
+
+// MessageScheduler schedules messages to be posted later, as implemented
+// by slack.Client
+type MessageScheduler interface {
+	ScheduleMessage(channel string, postAt time.Time, text string) (string, error)
+}
+
+// RemindConfig is read from handlers.remind in the bot's config
+type RemindConfig struct {
+	// MaxDelay caps how far ahead reminders can be scheduled, on top of
+	// Slack's own limit of 120 days. Zero leaves only Slack's limit.
+	MaxDelay time.Duration `mapstructure:"maxdelay"`
+}
+
+type RemindHandler struct {
+	scheduler MessageScheduler
+	config    RemindConfig
+}
+
+func NewRemindHandler(scheduler MessageScheduler, config RemindConfig) slack.SlackSlashCommandHandler {
+	return RemindHandler{scheduler, config}
+}
+
+func (a RemindHandler) Handle(arguments []string, request slack.SlackSlashCommandBody) (*slack.SlackResponse, error) {
+	if len(arguments) < 2 {
+		return nil, fmt.Errorf("a delay and a message are required\n%s", slack.UsageString(a))
+	}
+	delay, err := time.ParseDuration(arguments[0])
+	if err != nil || delay <= 0 {
+		return nil, fmt.Errorf("%s is not a valid delay, use something like 10m or 2h30m\n%s", arguments[0], slack.UsageString(a))
+	}
+
+	if a.config.MaxDelay > 0 && delay > a.config.MaxDelay {
+		return nil, fmt.Errorf("reminders can be scheduled at most %s ahead", a.config.MaxDelay)
+	}
+
+	text := strings.Join(arguments[1:], " ")
+	_, err = a.scheduler.ScheduleMessage(request.ChannelID, time.Now().Add(delay), text)
+	if err != nil {
+		return nil, fmt.Errorf("could not schedule the reminder: %w", err)
+	}
+
+	return &slack.SlackResponse{
+		ResponseType: "ephemeral",
+		Text:         fmt.Sprintf("I'll post \"%s\" in this channel in %s", text, delay),
+	}, nil
+}
+
+func (a RemindHandler) CommandName() string {
+	return "remind"
+}
+
+func (a RemindHandler) CommandArguments() string {
+	return "<delay> <message...>"
+}
+
+func (a RemindHandler) CommandDescription() string {
+	return "Posts a message to the channel after a delay such as 10m or 2h30m, up to 120 days ahead"
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pauwels-labs/slack-bot/pkg/slack"
+	"github.com/pauwels-labs/slack-bot/pkg/slack/slacktest"
+)
+
+type recordingScheduler struct {
+	channel string
+	postAt  time.Time
+	text    string
+	err     error
+}
+
+func (s *recordingScheduler) ScheduleMessage(channel string, postAt time.Time, text string) (string, error) {
+	s.channel, s.postAt, s.text = channel, postAt, text
+	return "Q123", s.err
+}
+
+func TestRemindSchedulesMessage(t *testing.T) {
+	scheduler := &recordingScheduler{}
+	before := time.Now()
+	response, err := NewRemindHandler(scheduler, RemindConfig{}).Handle([]string{"10m", "daily", "standup"}, slack.SlackSlashCommandBody{ChannelID: "C123"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if scheduler.channel != "C123" || scheduler.text != "daily standup" {
+		t.Errorf("unexpected message %q scheduled in %q", scheduler.text, scheduler.channel)
+	}
+	if delay := scheduler.postAt.Sub(before); delay < 10*time.Minute || delay > 10*time.Minute+time.Second {
+		t.Errorf("expected the message to be scheduled in 10m, got %s", delay)
+	}
+	if response.ResponseType != "ephemeral" {
+		t.Errorf("expected an ephemeral confirmation, got %s", response.ResponseType)
+	}
+}
+
+func TestRemindInvalidArguments(t *testing.T) {
+	for _, arguments := range [][]string{{}, {"10m"}, {"soon", "standup"}, {"-5m", "standup"}} {
+		_, err := NewRemindHandler(&recordingScheduler{}, RemindConfig{}).Handle(arguments, slack.SlackSlashCommandBody{})
+		if err == nil {
+			t.Errorf("expected an error for %v", arguments)
+		}
+	}
+}
+
+func TestRemindSchedulingError(t *testing.T) {
+	scheduler := &recordingScheduler{err: errors.New("channel_not_found")}
+	_, err := NewRemindHandler(scheduler, RemindConfig{}).Handle([]string{"10m", "standup"}, slack.SlackSlashCommandBody{})
+	if err == nil || !errors.Is(err, scheduler.err) {
+		t.Errorf("expected the scheduling error, got %v", err)
+	}
+}
+
+func TestRemindMaxDelayFromConfig(t *testing.T) {
+	scheduler := &recordingScheduler{}
+	handler := NewRemindHandler(scheduler, RemindConfig{MaxDelay: time.Hour})
+
+	_, err := handler.Handle([]string{"2h", "standup"}, slack.SlackSlashCommandBody{})
+	if err == nil {
+		t.Errorf("expected a reminder past the configured maximum to be refused")
+	}
+	if len(scheduler.text) > 0 {
+		t.Errorf("a reminder past the configured maximum was scheduled")
+	}
+
+	_, err = handler.Handle([]string{"30m", "standup"}, slack.SlackSlashCommandBody{})
+	if err != nil {
+		t.Errorf("unexpected error within the configured maximum: %v", err)
+	}
+}
+
+func TestRemindWithFakeClient(t *testing.T) {
+	client := slacktest.NewFakeClient()
+	_, err := NewRemindHandler(client, RemindConfig{}).Handle([]string{"5m", "deploy", "done?"}, slack.SlackSlashCommandBody{ChannelID: "C123"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	calls := client.CallsTo("ScheduleMessage")
+	if len(calls) != 1 || calls[0].Channel != "C123" || calls[0].Text != "deploy done?" {
+		t.Errorf("expected \"deploy done?\" to be scheduled in C123, got %+v", client.Calls())
+	}
+
+	client.Errors["ScheduleMessage"] = errors.New("channel_not_found")
+	_, err = NewRemindHandler(client, RemindConfig{}).Handle([]string{"5m", "standup"}, slack.SlackSlashCommandBody{ChannelID: "C123"})
+	if !errors.Is(err, client.Errors["ScheduleMessage"]) {
+		t.Errorf("expected the fake client's error, got %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/pauwels-labs/slack-bot/pkg/slack"
+)
+
+type WhoAmIHandler struct {
+}
+
+func NewWhoAmIHandler() slack.SlackSlashCommandHandler {
+	return WhoAmIHandler{}
+}
+
+func (a WhoAmIHandler) Handle(arguments []string, request slack.SlackSlashCommandBody) (*slack.SlackResponse, error) {
+	// Only the caller's own metadata is revealed, so nothing is redacted
+	text := fmt.Sprintf("```\nuser:    %s (%s)\nteam:    %s (%s)\nchannel: %s (%s)\ncommand: %s\napp:     %s\n```",
+		request.UserID, request.UserName,
+		request.TeamID, request.TeamDomain,
+		request.ChannelID, request.ChannelName,
+		request.Command,
+		request.APIAppID,
+	)
+
+	return &slack.SlackResponse{
+		ResponseType: "ephemeral",
+		Text:         text,
+	}, nil
+}
+
+func (a WhoAmIHandler) CommandName() string {
+	return "whoami"
+}
+
+func (a WhoAmIHandler) CommandArguments() string {
+	return ""
+}
+
+func (a WhoAmIHandler) CommandDescription() string {
+	return "Displays the metadata Slack sent along with your command, useful for debugging the integration"
+}
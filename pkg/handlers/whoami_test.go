@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pauwels-labs/slack-bot/pkg/slack"
+)
+
+func TestWhoAmIIncludesCallerMetadata(t *testing.T) {
+	response, err := NewWhoAmIHandler().Handle([]string{}, slack.SlackSlashCommandBody{
+		Command:     "/bot",
+		UserID:      "U0123",
+		UserName:    "jane",
+		TeamID:      "T0123",
+		ChannelID:   "C0123",
+		ChannelName: "general",
+	})
+	if err != nil {
+		t.Fatalf("whoami returned an error: %v", err)
+	}
+
+	if response.ResponseType != "ephemeral" {
+		t.Errorf("expected an ephemeral response, got %s", response.ResponseType)
+	}
+	for _, expected := range []string{"U0123", "C0123", "general", "/bot"} {
+		if !strings.Contains(response.Text, expected) {
+			t.Errorf("response %q is missing %s", response.Text, expected)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package slack
+
+import (
+	"context"
+	"time"
+)
+
+// SlackAPI calls Slack's Web API, as implemented by Client. Code needing
+// the Web API depends on it rather than on Client so that it can be
+// given a fake in tests, or a wrapper such as a rate limiter.
+type SlackAPI interface {
+	ScheduleMessage(channel string, postAt time.Time, text string) (string, error)
+	PostMessage(channel string, text string) (string, error)
+	PostBlocks(channel string, text string, blocks []Block) (string, error)
+	DeleteMessage(channel string, ts string) error
+	AddReaction(channel string, timestamp string, emoji string) error
+	IsMember(channel string) (bool, error)
+	AuthTest() error
+	UploadFile(channel string, filename string, content []byte) (string, error)
+}
+
+var _ SlackAPI = (*Client)(nil)
+
+type slackAPIContextKey struct{}
+
+func withSlackAPI(ctx context.Context, api SlackAPI) context.Context {
+	if api == nil {
+		return ctx
+	}
+
+	return context.WithValue(ctx, slackAPIContextKey{}, api)
+}
+
+// SlackAPIFromContext returns the Web API client given to the bot with
+// WithWebAPIClient, if it has one, for handlers implementing
+// SlackSlashCommandContextHandler
+func SlackAPIFromContext(ctx context.Context) (SlackAPI, bool) {
+	api, ok := ctx.Value(slackAPIContextKey{}).(SlackAPI)
+	return api, ok
+}
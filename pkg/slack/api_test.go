@@ -0,0 +1,73 @@
+package slack
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// fakeSlackAPI records posted messages, any other call panicking through
+// the embedded nil SlackAPI
+type fakeSlackAPI struct {
+	SlackAPI
+	posts chan [2]string
+}
+
+func (f fakeSlackAPI) PostMessage(channel string, text string) (string, error) {
+	f.posts <- [2]string{channel, text}
+	return "1.2", nil
+}
+
+// announceHandler posts an announcement with the bot's Web API client
+type announceHandler struct {
+	recordingHandler
+}
+
+func (h announceHandler) HandleContext(ctx context.Context, arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	api, ok := SlackAPIFromContext(ctx)
+	if !ok {
+		return &SlackResponse{Text: "no client"}, nil
+	}
+	ts, err := api.PostMessage(request.ChannelID, "deploy finished")
+	if err != nil {
+		return nil, err
+	}
+
+	return &SlackResponse{Text: "announced " + ts}, nil
+}
+
+func TestHandlersUseTheInjectedSlackAPI(t *testing.T) {
+	api := fakeSlackAPI{posts: make(chan [2]string, 1)}
+	server, responses := newResponseServer(t)
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{announceHandler{recordingHandler{name: "announce"}}}, WithWebAPIClient(api))
+
+	handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+		"text":         {"announce"},
+		"channel_id":   {"C1"},
+		"response_url": {server.URL},
+	}))
+
+	if post := <-api.posts; post != [2]string{"C1", "deploy finished"} {
+		t.Errorf("expected the announcement to be posted to C1 with the fake, got %v", post)
+	}
+	if response := receiveResponse(t, responses); response.Text != "announced 1.2" {
+		t.Errorf("expected the fake's timestamp in the response, got %q", response.Text)
+	}
+}
+
+func TestSlackAPIFromContextWithoutClient(t *testing.T) {
+	server, responses := newResponseServer(t)
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{announceHandler{recordingHandler{name: "announce"}}})
+
+	handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+		"text":         {"announce"},
+		"response_url": {server.URL},
+	}))
+
+	if response := receiveResponse(t, responses); response.Text != "no client" {
+		t.Errorf("expected no client without WithWebAPIClient, got %q", response.Text)
+	}
+}
@@ -0,0 +1,108 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// BatchResult is the outcome of processing a single item of a batch
+type BatchResult struct {
+	Item string
+	Err  error
+}
+
+// BatchResults are the outcomes of a batch, in the order of its items
+type BatchResults []BatchResult
+
+// RunBatch processes every item with process, running at most
+// concurrency of them at a time, for handlers such as `deploy svcA svcB`
+// that treat each argument as a separate task. After each item
+// completes, progress is reported through the context's
+// ProgressReporter. Items that haven't started when the context is done
+// fail with its error.
+func RunBatch(ctx context.Context, items []string, concurrency int, process func(ctx context.Context, item string) error) BatchResults {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	progress := ProgressReporterFromContext(ctx)
+
+	results := make(BatchResults, len(items))
+	semaphore := make(chan struct{}, concurrency)
+	var mu sync.Mutex
+	completed, failed := 0, 0
+	var wg sync.WaitGroup
+	for i, item := range items {
+		results[i].Item = item
+
+		// Wait for a free slot, giving up on the remaining items if the
+		// context is done first
+		if ctx.Err() != nil {
+			results[i].Err = ctx.Err()
+			continue
+		}
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, item string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			err := process(ctx, item)
+			results[i].Err = err
+
+			mu.Lock()
+			defer mu.Unlock()
+			completed++
+			if err != nil {
+				failed++
+			}
+			progress.Update(fmt.Sprintf("%d of %d done, %d failed", completed, len(items), failed))
+		}(i, item)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// Succeeded returns the number of items processed without an error
+func (r BatchResults) Succeeded() int {
+	succeeded := 0
+	for _, result := range r {
+		if result.Err == nil {
+			succeeded++
+		}
+	}
+
+	return succeeded
+}
+
+// Failed returns the number of items that failed
+func (r BatchResults) Failed() int {
+	return len(r) - r.Succeeded()
+}
+
+// Summary builds an ephemeral response counting the successes and
+// failures, followed by the outcome of each item. It replaces the
+// progress message if one was posted.
+func (r BatchResults) Summary() *SlackResponse {
+	lines := []string{fmt.Sprintf("%d succeeded, %d failed", r.Succeeded(), r.Failed())}
+	for _, result := range r {
+		if result.Err != nil {
+			lines = append(lines, fmt.Sprintf(":x: %s: %s", result.Item, result.Err))
+		} else {
+			lines = append(lines, fmt.Sprintf(":white_check_mark: %s", result.Item))
+		}
+	}
+
+	return &SlackResponse{
+		ResponseType:    "ephemeral",
+		Text:            strings.Join(lines, "\n"),
+		ReplaceOriginal: true,
+	}
+}
@@ -0,0 +1,57 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type recordingProgressReporter struct {
+	mu      sync.Mutex
+	updates []string
+}
+
+func (p *recordingProgressReporter) Update(text string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.updates = append(p.updates, text)
+}
+
+func TestRunBatchSummarizesSuccessesAndFailures(t *testing.T) {
+	progress := &recordingProgressReporter{}
+	ctx := withProgressReporter(context.Background(), progress)
+	results := RunBatch(ctx, []string{"svcA", "svcB", "svcC"}, 2, func(ctx context.Context, item string) error {
+		if item == "svcB" {
+			return errors.New("rollout timed out")
+		}
+		return nil
+	})
+
+	if results.Succeeded() != 2 || results.Failed() != 1 {
+		t.Errorf("expected 2 successes and 1 failure, got %d and %d", results.Succeeded(), results.Failed())
+	}
+	summary := results.Summary()
+	expected := "2 succeeded, 1 failed\n:white_check_mark: svcA\n:x: svcB: rollout timed out\n:white_check_mark: svcC"
+	if summary.Text != expected {
+		t.Errorf("unexpected summary %q", summary.Text)
+	}
+	if len(progress.updates) != 3 || !strings.HasPrefix(progress.updates[2], "3 of 3 done, 1 failed") {
+		t.Errorf("expected a progress update per item, got %q", progress.updates)
+	}
+}
+
+func TestRunBatchStopsStartingItemsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results := RunBatch(ctx, []string{"svcA", "svcB"}, 1, func(ctx context.Context, item string) error {
+		return nil
+	})
+
+	for _, result := range results {
+		if !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("expected %s not to start once the context is cancelled, got %v", result.Item, result.Err)
+		}
+	}
+}
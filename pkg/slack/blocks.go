@@ -0,0 +1,161 @@
+package slack
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Slack limits the number of blocks a single message may contain
+const maxBlocksPerMessage = 50
+
+type TextObject struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Block is a Block Kit layout block. Blocks decoded from Slack, such as
+// those of a message being interacted with, keep the JSON they were
+// received as and are encoded back to it unless they are modified, so
+// that fields not modelled here survive the round trip.
+type Block struct {
+	Type     string        `json:"type"`
+	BlockID  string        `json:"block_id,omitempty"`
+	Text     *TextObject   `json:"text,omitempty"`
+	Fields   []*TextObject `json:"fields,omitempty"`
+	Elements []interface{} `json:"elements,omitempty"`
+	original json.RawMessage
+}
+
+// blockFields has the same fields as Block without its JSON methods
+type blockFields Block
+
+func (b *Block) UnmarshalJSON(data []byte) error {
+	var fields blockFields
+	err := json.Unmarshal(data, &fields)
+	if err != nil {
+		return err
+	}
+	*b = Block(fields)
+	b.original = append(json.RawMessage{}, data...)
+
+	return nil
+}
+
+func (b Block) MarshalJSON() ([]byte, error) {
+	// Reuse the JSON the block was decoded from if it hasn't changed since
+	if b.original != nil {
+		var decoded Block
+		err := decoded.UnmarshalJSON(b.original)
+		if err == nil && reflect.DeepEqual(blockFields(decoded), blockFields(b)) {
+			return b.original, nil
+		}
+	}
+
+	fields := blockFields(b)
+	fields.original = nil
+	return json.Marshal(fields)
+}
+
+// ButtonElement is an interactive button, which sends a block_actions
+// interaction with its action ID and value when clicked
+type ButtonElement struct {
+	Type     string      `json:"type"`
+	Text     *TextObject `json:"text"`
+	ActionID string      `json:"action_id"`
+	Value    string      `json:"value,omitempty"`
+	Style    string      `json:"style,omitempty"`
+}
+
+// Slack rejects buttons whose value is longer than this
+const maxButtonValueLength = 2000
+
+// NewButtonElement creates a button, its style can be set to `primary`
+// or `danger` to reflect state such as a selection
+func NewButtonElement(actionID string, text string, value string) ButtonElement {
+	return ButtonElement{
+		Type:     "button",
+		Text:     NewPlainText(text),
+		ActionID: actionID,
+		Value:    value,
+	}
+}
+
+// NewActionsBlock creates a block of interactive elements, identified by
+// blockID so that it can be replaced when its state changes
+func NewActionsBlock(blockID string, elements ...interface{}) Block {
+	return Block{
+		Type:     "actions",
+		BlockID:  blockID,
+		Elements: elements,
+	}
+}
+
+func NewPlainText(text string) *TextObject {
+	return &TextObject{
+		Type: "plain_text",
+		Text: text,
+	}
+}
+
+func NewMarkdownText(text string) *TextObject {
+	return &TextObject{
+		Type: "mrkdwn",
+		Text: text,
+	}
+}
+
+func NewHeaderBlock(text string) Block {
+	return Block{
+		Type: "header",
+		Text: NewPlainText(text),
+	}
+}
+
+func NewSectionBlock(text *TextObject) Block {
+	return Block{
+		Type: "section",
+		Text: text,
+	}
+}
+
+func NewContextBlock(elements ...*TextObject) Block {
+	contextElements := make([]interface{}, len(elements))
+	for i, element := range elements {
+		contextElements[i] = element
+	}
+
+	return Block{
+		Type:     "context",
+		Elements: contextElements,
+	}
+}
+
+// decorate returns a copy of response with prefix and suffix around its
+// text, and as context blocks around its blocks if it has any and there
+// is room for them
+func decorate(response *SlackResponse, prefix string, suffix string) *SlackResponse {
+	if len(prefix) == 0 && len(suffix) == 0 {
+		return response
+	}
+
+	decorated := *response
+	decorated.Text = prefix + response.Text + suffix
+	if len(response.Blocks) == 0 {
+		return &decorated
+	}
+
+	blocks := []Block{}
+	if trimmed := strings.TrimSpace(prefix); len(trimmed) > 0 {
+		blocks = append(blocks, NewContextBlock(NewMarkdownText(trimmed)))
+	}
+	blocks = append(blocks, response.Blocks...)
+	if trimmed := strings.TrimSpace(suffix); len(trimmed) > 0 {
+		blocks = append(blocks, NewContextBlock(NewMarkdownText(trimmed)))
+	}
+	if len(blocks) <= maxBlocksPerMessage {
+		decorated.Blocks = blocks
+	}
+
+	return &decorated
+}
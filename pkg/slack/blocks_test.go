@@ -0,0 +1,26 @@
+package slack
+
+import (
+	"testing"
+)
+
+func TestDecorateAddsContextBlocks(t *testing.T) {
+	response := &SlackResponse{
+		Text:   "fallback",
+		Blocks: []Block{NewSectionBlock(NewMarkdownText("body"))},
+	}
+
+	decorated := decorate(response, "[staging] ", "")
+	if decorated.Text != "[staging] fallback" {
+		t.Errorf("unexpected text %q", decorated.Text)
+	}
+	if len(decorated.Blocks) != 2 || decorated.Blocks[0].Type != "context" || decorated.Blocks[1].Text.Text != "body" {
+		t.Fatalf("expected a context block before the body, got %+v", decorated.Blocks)
+	}
+	if element := decorated.Blocks[0].Elements[0].(*TextObject); element.Text != "[staging]" {
+		t.Errorf("unexpected context text %q", element.Text)
+	}
+	if len(response.Blocks) != 1 || response.Text != "fallback" {
+		t.Error("expected the original response to be left untouched")
+	}
+}
@@ -2,20 +2,35 @@ package slack
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/mitchellh/mapstructure"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"io"
+	"mime"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
+// SlackSlashCommandHandler handles a single command. Returning a nil
+// response with a nil error acknowledges the command without posting any
+// message, for commands that should succeed silently.
 type SlackSlashCommandHandler interface {
 	Handle(arguments []string, request SlackSlashCommandBody) (*SlackResponse, error)
 	CommandName() string
@@ -23,10 +38,79 @@ type SlackSlashCommandHandler interface {
 	CommandDescription() string
 }
 
+// Outbound posts to Slack are abandoned after this long
+const responseTimeout = 10 * time.Second
+
+// Stops posting responses for a while when Slack appears to be degraded
+var responseBreaker = NewCircuitBreaker("response_url", 5, 30*time.Second, isDegradationFailure)
+
+// Slack expects slash commands to be acknowledged within this long of
+// the request timestamp
+const acknowledgementWindow = 3 * time.Second
+
+// SlackSlashCommandContextHandler may be implemented by handlers that want
+// access to the request context, which carries helpers such as the
+// ProgressReporter and the Conversation. HandleContext is called instead
+// of Handle.
+type SlackSlashCommandContextHandler interface {
+	SlackSlashCommandHandler
+	HandleContext(ctx context.Context, arguments []string, request SlackSlashCommandBody) (*SlackResponse, error)
+}
+
+// SlackSlashCommandDeferredHandler may be implemented by handlers that
+// take longer than Slack's three second acknowledgement window. When
+// Deferred returns true, the request is acknowledged immediately and the
+// handler runs in the background, delivering its response to the
+// response_url once it completes.
+type SlackSlashCommandDeferredHandler interface {
+	SlackSlashCommandHandler
+	Deferred() bool
+}
+
+// SlackSlashCommandAcknowledgingHandler may be implemented by handlers
+// running in the background, typically deferred ones, to show the user an
+// ephemeral message straight away while they work. A non-empty
+// Acknowledgement is sent back in the body of Slack's request, and the
+// handler's response follows through the response_url once it completes.
+type SlackSlashCommandAcknowledgingHandler interface {
+	SlackSlashCommandHandler
+	Acknowledgement(arguments []string, request SlackSlashCommandBody) string
+}
+
+// SlackSlashCommandKeyedHandler may be implemented by handlers backing a
+// single-purpose slash command such as `/standup`. Requests whose command
+// matches SlashCommand are routed to the handler with the whole text as
+// arguments, which may be empty.
+type SlackSlashCommandKeyedHandler interface {
+	SlackSlashCommandHandler
+	SlashCommand() string
+}
+
+// SlackSlashCommandDeprecatedHandler may be implemented by handlers for
+// commands that are being phased out. When DeprecatedInFavorOf returns
+// the name of a replacement, responses to the command start with a note
+// pointing users to it.
+type SlackSlashCommandDeprecatedHandler interface {
+	SlackSlashCommandHandler
+	DeprecatedInFavorOf() string
+}
+
 type SlackBot struct {
-	port       uint16
-	signingKey string
-	handlers   []SlackSlashCommandHandler
+	port     uint16
+	secrets  SecretSource
+	handlers *atomic.Pointer[[]SlackSlashCommandHandler]
+	options  []SlackBotOption
+	mounts   []slackBotMount
+	server   *http.Server
+}
+
+// slackBotMount is an additional Slack app served by the same bot under
+// its own path
+type slackBotMount struct {
+	path     string
+	secrets  SecretSource
+	handlers []SlackSlashCommandHandler
+	options  []SlackBotOption
 }
 
 type SlackSlashCommandBody struct {
@@ -35,112 +119,394 @@ type SlackSlashCommandBody struct {
 	ResponseURL string `mapstructure:"response_url,omitempty"`
 	TriggerID   string `mapstructure:"trigger_id,omitempty"`
 	UserID      string `mapstructure:"user_id,omitempty"`
-	APIAppID    string `mapstructure:"api_add_id,omitempty"`
+	UserName    string `mapstructure:"user_name,omitempty"`
+	TeamID      string `mapstructure:"team_id,omitempty"`
+	TeamDomain  string `mapstructure:"team_domain,omitempty"`
+	ChannelID   string `mapstructure:"channel_id,omitempty"`
+	ChannelName string `mapstructure:"channel_name,omitempty"`
+	APIAppID    string `mapstructure:"api_app_id,omitempty"`
+	ThreadTS    string `mapstructure:"thread_ts,omitempty"`
 	SSLCheck    string `mapstructure:"ssl_check,omitempty"`
+	// Token is Slack's deprecated verification token, it is redacted
+	// whenever the body is logged or formatted
+	Token string `mapstructure:"token,omitempty"`
 }
 
-type SlackResponse struct {
-	ResponseType string `json:"response_type,omitempty"`
-	Text         string `json:"text,omitempty"`
+const redacted = "[redacted]"
+
+// redact returns a copy of the body safe to log
+func (b SlackSlashCommandBody) redact() SlackSlashCommandBody {
+	if len(b.Token) > 0 {
+		b.Token = redacted
+	}
+
+	return b
 }
 
-func NewSlackBot(port uint16, signingKey string, handlers []SlackSlashCommandHandler) SlackBot {
-	helpHandler := NewHelpHandler(&handlers)
-	handlers = append(handlers, helpHandler)
+// String formats the body with its token redacted
+func (b SlackSlashCommandBody) String() string {
+	type plain SlackSlashCommandBody
+	return fmt.Sprintf("%+v", plain(b.redact()))
+}
+
+// GoString formats the body with its token redacted
+func (b SlackSlashCommandBody) GoString() string {
+	type plain SlackSlashCommandBody
+	return fmt.Sprintf("%#v", plain(b.redact()))
+}
+
+// MarshalLogObject logs the body with its token redacted
+func (b SlackSlashCommandBody) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	b = b.redact()
+	encoder.AddString("command", b.Command)
+	encoder.AddString("text", b.Text)
+	encoder.AddString("responseURL", b.ResponseURL)
+	encoder.AddString("triggerID", b.TriggerID)
+	encoder.AddString("userID", b.UserID)
+	encoder.AddString("userName", b.UserName)
+	encoder.AddString("teamID", b.TeamID)
+	encoder.AddString("teamDomain", b.TeamDomain)
+	encoder.AddString("channelID", b.ChannelID)
+	encoder.AddString("channelName", b.ChannelName)
+	encoder.AddString("apiAppID", b.APIAppID)
+	encoder.AddString("threadTS", b.ThreadTS)
+	encoder.AddString("sslCheck", b.SSLCheck)
+	encoder.AddString("token", b.Token)
+
+	return nil
+}
 
-	return SlackBot{
+type SlackResponse struct {
+	ResponseType    string  `json:"response_type,omitempty"`
+	Text            string  `json:"text,omitempty"`
+	Blocks          []Block `json:"blocks,omitempty"`
+	ReplaceOriginal bool    `json:"replace_original,omitempty"`
+	// Channel posts the response to another channel than the command's,
+	// with the Web API, instead of the response_url
+	Channel string `json:"-"`
+}
+
+// NewSlackBot creates a bot verifying requests with the signing key from
+// secrets, which is looked up for every request so that rotated keys are
+// used as soon as the source provides them
+func NewSlackBot(port uint16, secrets SecretSource, handlers []SlackSlashCommandHandler, options ...SlackBotOption) SlackBot {
+	sb := SlackBot{
 		port,
-		signingKey,
-		handlers,
+		secrets,
+		&atomic.Pointer[[]SlackSlashCommandHandler]{},
+		options,
+		[]slackBotMount{},
+		&http.Server{Addr: fmt.Sprintf(":%d", port)},
+	}
+	sb.SetHandlers(handlers)
+
+	// Let operators confirm the expected commands are active
+	opts := newSlackBotOptions(options)
+	logCommands(opts.logger, *sb.handlers.Load(), opts.namespaceSeparator)
+
+	return sb
+}
+
+// logCommands logs every registered command on its own line, along with
+// how it is reached and shown
+func logCommands(logger *zap.Logger, handlers []SlackSlashCommandHandler, namespaceSeparator string) {
+	for _, handler := range handlers {
+		fields := []zap.Field{
+			zap.String("command", qualifiedName(handler, namespaceSeparator)),
+			zap.Bool("hidden", isHidden(handler)),
+		}
+		unwrapped := unwrapNamespace(handler)
+		if keyedHandler, ok := unwrapped.(SlackSlashCommandKeyedHandler); ok {
+			fields = append(fields, zap.String("slashCommand", keyedHandler.SlashCommand()))
+		}
+		if deferredHandler, ok := unwrapped.(SlackSlashCommandDeferredHandler); ok {
+			fields = append(fields, zap.Bool("deferred", deferredHandler.Deferred()))
+		}
+		if _, ok := unwrapped.(SlackSlashCommandConfirmingHandler); ok {
+			fields = append(fields, zap.Bool("confirmed", true))
+		}
+		if deprecatedHandler, ok := unwrapped.(SlackSlashCommandDeprecatedHandler); ok && len(deprecatedHandler.DeprecatedInFavorOf()) > 0 {
+			fields = append(fields, zap.String("deprecatedInFavorOf", deprecatedHandler.DeprecatedInFavorOf()))
+		}
+		logger.Info("registered command", fields...)
+	}
+}
+
+// SetHandlers replaces the handlers of the app served from the root path
+// while the bot is running, along with its help command. Requests already
+// being handled finish with the previous handlers.
+func (sb *SlackBot) SetHandlers(handlers []SlackSlashCommandHandler) {
+	withHelp := withHelpHandler(append([]SlackSlashCommandHandler{}, handlers...), sb.options)
+	sb.handlers.Store(&withHelp)
+}
+
+// Mount adds another Slack app to the bot, served under the given path
+// with its own secrets, handlers, and options. Apps mounted this way
+// share the bot's port, while the app passed to NewSlackBot is served
+// from the root path.
+func (sb *SlackBot) Mount(path string, secrets SecretSource, handlers []SlackSlashCommandHandler, options ...SlackBotOption) error {
+	if !strings.HasPrefix(path, "/") || path == "/" {
+		return fmt.Errorf("mount path %q must start with a slash and not be the root path", path)
+	}
+	if path == healthPath || path == readinessPath {
+		return fmt.Errorf("mount path %q is reserved for health checks", path)
+	}
+	for _, mount := range sb.mounts {
+		if mount.path == path {
+			return fmt.Errorf("mount path %q is already in use", path)
+		}
+	}
+
+	sb.mounts = append(sb.mounts, slackBotMount{
+		path,
+		secrets,
+		withHelpHandler(handlers, options),
+		options,
+	})
+
+	return nil
+}
+
+// InvokeCommand routes text to the bot's handlers and runs the matching
+// one as if Slack had sent it, without signature verification or HTTP,
+// returning the handler's response and error as is. It is meant for
+// testing handlers, so nothing is posted to the body's response_url.
+func (sb *SlackBot) InvokeCommand(ctx context.Context, text string, body SlackSlashCommandBody) (*SlackResponse, error) {
+	body.Text = text
+	opts := newSlackBotOptions(sb.options)
+	handler, commandArguments := route(*sb.handlers.Load(), body, opts)
+	if handler == nil {
+		return nil, fmt.Errorf("no handler matches %q", text)
 	}
+
+	return invoke(withCaseSensitiveCommands(ctx, opts.caseSensitiveCommands), handler, commandArguments, body)
 }
 
 func (sb *SlackBot) ListenAndServe(logger *zap.Logger) error {
+	sb.server.Handler = sb.buildHandler(logger)
+
+	// Serve on a unix domain socket instead of the port if configured
+	socketPath := newSlackBotOptions(sb.options).unixSocket
+	if len(socketPath) == 0 {
+		return sb.server.ListenAndServe()
+	}
+
+	// Remove a socket left behind by a previous run, the listener removes
+	// its own socket once closed
+	if info, err := os.Stat(socketPath); err == nil && info.Mode()&os.ModeSocket != 0 {
+		err = os.Remove(socketPath)
+		if err != nil {
+			return fmt.Errorf("could not remove stale socket %s: %w", socketPath, err)
+		}
+	}
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return err
+	}
+	logger.Info("listening on unix socket", zap.String("path", socketPath))
+
+	return sb.server.Serve(listener)
+}
+
+// buildHandler wraps the mux in a timeout, if configured, bounding how
+// long any request may take regardless of its handler
+func (sb *SlackBot) buildHandler(logger *zap.Logger) http.Handler {
+	mux := sb.buildMux(logger)
+	timeout := newSlackBotOptions(sb.options).requestTimeout
+	if timeout <= 0 {
+		return mux
+	}
+
+	return http.TimeoutHandler(mux, timeout, "request timed out")
+}
+
+func (sb *SlackBot) buildMux(logger *zap.Logger) *http.ServeMux {
 	mux := http.NewServeMux()
-	mux.HandleFunc("/", BuildHandler(logger, sb.signingKey, sb.handlers))
-	return http.ListenAndServe(fmt.Sprintf(":%d", sb.port), mux)
+	rootHandler := buildRequestHandler(logger, sb.secrets, func() []SlackSlashCommandHandler {
+		return *sb.handlers.Load()
+	}, sb.options)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		// The root pattern matches every path, only the root itself is
+		// the command endpoint
+		if r.URL.Path != "/" {
+			writeJSONError(w, http.StatusNotFound, "not found")
+			return
+		}
+		rootHandler(w, r)
+	})
+	readiness := newSlackBotOptions(sb.options).readiness
+	mux.HandleFunc(healthPath, healthHandler(readiness, false))
+	mux.HandleFunc(readinessPath, healthHandler(readiness, true))
+	for _, mount := range sb.mounts {
+		mux.HandleFunc(mount.path, BuildHandler(logger.With(zap.String("mount", mount.path)), mount.secrets, mount.handlers, mount.options...))
+	}
+
+	return mux
+}
+
+func withHelpHandler(handlers []SlackSlashCommandHandler, options []SlackBotOption) []SlackSlashCommandHandler {
+	opts := newSlackBotOptions(options)
+	handlers = EnabledHandlers(handlers, opts.featureFlags)
+	registerHandlerMetrics(opts.metricsRegisterer, handlers)
+	helpHandler := newHelpHandler(opts.helpCommandName, &handlers, opts.showHiddenCommands, opts.helpAdmins)
+	helpHandler.messageLimit = opts.helpMessageLimit
+	helpHandler.namespaceSeparator = opts.namespaceSeparator
+	handlers = append(handlers, helpHandler)
+
+	// Suggest completions too, unless a handler already uses the name
+	for _, handler := range handlers {
+		if matchesCommand(handler.CommandName(), completeCommandName, opts.caseSensitiveCommands) {
+			return handlers
+		}
+	}
+	handlers = append(handlers, CompletionHandler{helpHandler})
+
+	return handlers
+}
+
+// Shutdown stops the bot from accepting new connections and waits for
+// in-flight requests to complete, or for the context to expire
+func (sb *SlackBot) Shutdown(ctx context.Context) error {
+	return sb.server.Shutdown(ctx)
+}
+
+func BuildHandler(logger *zap.Logger, secrets SecretSource, handlers []SlackSlashCommandHandler, options ...SlackBotOption) func(http.ResponseWriter, *http.Request) {
+	return buildRequestHandler(logger, secrets, func() []SlackSlashCommandHandler {
+		return handlers
+	}, options)
 }
 
-func BuildHandler(logger *zap.Logger, signingKey string, handlers []SlackSlashCommandHandler) func(http.ResponseWriter, *http.Request) {
+// buildRequestHandler builds the request handler, looking up the current
+// handlers for every request
+func buildRequestHandler(logger *zap.Logger, secrets SecretSource, currentHandlers func() []SlackSlashCommandHandler, options []SlackBotOption) func(http.ResponseWriter, *http.Request) {
+	opts := newSlackBotOptions(options)
+	if opts.retries == nil && opts.retryWindow > 0 {
+		opts.retries = NewRetryDeduplicator(opts.retryWindow)
+	}
+	if opts.idempotency == nil {
+		opts.idempotency = NewIdempotencyCache()
+	}
+	if opts.conversations == nil {
+		opts.conversations = NewMemoryConversationStore(defaultConversationTTL)
+	}
+
 	return func(w http.ResponseWriter, r *http.Request) {
+		// Ensure a bug in verifying or parsing a single request answers it
+		// with a 500 rather than dropping the connection
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				logger.Error("request handling panicked", zap.Any("panic", recovered), zap.Stack("stack"))
+				http.Error(w, "internal error", http.StatusInternalServerError)
+			}
+		}()
+
+		// Trace the request, the span ends once it has been answered
+		ctx, span := opts.tracerProvider.Tracer(tracerName).Start(r.Context(), "slack.request", trace.WithSpanKind(trace.SpanKindServer))
+		defer span.End()
+		r = r.WithContext(ctx)
+
+		// Ensure the bot is ready to process requests
+		if opts.readiness != nil && !opts.readiness.Ready() {
+			logger.Warn("rejecting request, bot is not ready")
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+
+		// Ensure the request comes from an allowed source, if restricted
+		if len(opts.allowedSourceRanges) > 0 {
+			source, err := clientIP(r, opts.trustedProxies)
+			if err != nil || !containsAddr(opts.allowedSourceRanges, source) {
+				logger.Error("request from disallowed source", zap.String("remoteAddr", r.RemoteAddr), zap.Stringer("source", source), zap.NamedError("reason", err))
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+		}
+
 		// Ensure the request uses the POST method
 		method := r.Method
 		if method != "POST" {
 			logger.Error("incorrect request method", zap.String("method", method))
+			w.Header().Set("allow", "POST")
+			writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
 			return
 		}
 
-		// Ensure the request uses the application/x-www-form-urlencoded content-type
+		// Ensure the request uses an allowed content-type, ignoring
+		// parameters such as a charset added by proxies
 		contentType := r.Header.Get("content-type")
-		if contentType != "application/x-www-form-urlencoded" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || !isAllowedMediaType(mediaType, opts.allowedContentTypes) {
 			logger.Error("incorrect content-type", zap.String("contentType", contentType))
 			return
 		}
 
-		// Ensure the request includes a signature header
-		signatureHeader := r.Header.Get("x-slack-signature")
-		if len(signatureHeader) == 0 {
-			logger.Error("missing request x-slack-signature-header")
-			return
+		// Ensure the current signing key is available, unless requests
+		// are verified with the legacy verification token instead
+		signingKey, err := secrets.SigningKey()
+		if errors.Is(err, ErrNoSigningKey) {
+			err = nil
 		}
-
-		// Ensure the request includes a timestamp header
-		timestampHeader := []byte(r.Header.Get("x-slack-request-timestamp"))
-		if len(timestampHeader) == 0 {
-			logger.Error("missing request x-slack-request-timestamp header")
-			return
-		}
-
-		// Verify that timestamp is within +/- 5 minutes from now to prevent replay attacks
-		timestampHeaderInt, err := strconv.ParseInt(string(timestampHeader), 10, 64)
 		if err != nil {
-			logger.Error("timestamp header could not be converted to a UNIX epoch", zap.Error(err))
+			logger.Error("could not read signing key", zap.Error(err))
+			http.Error(w, "internal error", http.StatusInternalServerError)
 			return
 		}
-		givenTime := time.Unix(timestampHeaderInt, 0)
-		timeDiffInSeconds := time.Since(givenTime).Abs().Seconds()
-		if timeDiffInSeconds > 300 {
-			logger.Error("timestamp header is not within five minutes of current timestamp")
+		if len(signingKey) == 0 && len(opts.verificationToken) == 0 {
+			logger.Error("refusing request since neither a signing key nor a verification token is configured")
+			http.Error(w, "internal error", http.StatusInternalServerError)
 			return
 		}
 
-		// Generate a string of the request body
-		body, err := io.ReadAll(r.Body)
-		if err != nil {
-			logger.Error("unable to parse request body", zap.Error(err))
-			return
+		// Verify the request came from Slack, using the deprecated
+		// verification token only when no signing key is configured
+		var body []byte
+		var givenTime time.Time
+		var verified bool
+		_, verifySpan := startSpan(ctx, "slack.verify")
+		if len(signingKey) == 0 && len(opts.verificationToken) > 0 {
+			body, givenTime, verified = verifyTokenRequest(logger, w, r, opts.verificationToken)
+		} else {
+			body, givenTime, verified = verifySignedRequest(logger, w, r, signingKey)
 		}
-
-		// Create the secured request signature using the Slack signing key
-		baseString := fmt.Sprintf("v0:%s:%s", timestampHeader, body)
-		mac := hmac.New(sha256.New, []byte(signingKey))
-		bytesWritten, err := mac.Write([]byte(baseString))
-		if err != nil {
-			logger.Error("unable to compute request signature", zap.Error(err), zap.Int("bytesWritten", bytesWritten))
-			return
+		if !verified {
+			verifySpan.SetStatus(codes.Error, "request could not be verified")
 		}
-		signatureComputed := mac.Sum(nil)
-		signatureComputedHex := hex.EncodeToString(signatureComputed)
-		signatureComputedFormatted := fmt.Sprintf("v0=%s", signatureComputedHex)
-
-		// Compare the generated signature with the provided signature
-		if signatureComputedFormatted != signatureHeader {
-			logger.Error("computed signature and provided signature do not match", zap.String("computed", signatureComputedFormatted), zap.String("provided", signatureHeader))
+		verifySpan.End()
+		if !verified {
 			return
 		}
 
-		// Request is fully verified, acknowledge we've received it
-		w.WriteHeader(http.StatusOK)
+		// Request is fully verified, from here on it is acknowledged with a
+		// 200, which net/http sends once this function returns unless an
+		// acknowledgement message is written first
 
 		// Place the body string back in the request so we can parse individual form fields
 		r.Body = io.NopCloser(bytes.NewBuffer(body))
 
-		// Decode the body into a struct
+		// Decode the body into a struct, letting the user know if we can't
+		// make sense of it. Query parameters fill in fields missing from
+		// the body, which is handy for exercising the endpoint by hand,
+		// while the signature only ever covers the body.
 		err = r.ParseForm()
+
+		// If this is an SSL certificate verification, immediately stop
+		// execution, whatever else the form contains
+		if r.Form.Get("ssl_check") == "1" {
+			return
+		}
+
 		if err != nil {
 			logger.Error("unable to parse form values", zap.Error(err))
+			respondUnparseable(logger, opts.responder, r.Form.Get("response_url"))
+			return
+		}
+		// Interactions, such as button clicks, carry a JSON payload instead
+		// of a command
+		if payload := r.Form.Get("payload"); len(payload) > 0 {
+			handleInteraction(trace.ContextWithSpan(context.Background(), span), logger, opts, currentHandlers(), payload)
 			return
 		}
+
 		undecodedForm := map[string]string{}
 		for key, element := range r.Form {
 			undecodedForm[key] = element[0]
@@ -149,47 +515,521 @@ func BuildHandler(logger *zap.Logger, signingKey string, handlers []SlackSlashCo
 		err = mapstructure.Decode(undecodedForm, &slashCommandBody)
 		if err != nil {
 			logger.Error("unable to decode form values into struct", zap.Error(err))
+			respondUnparseable(logger, opts.responder, r.Form.Get("response_url"))
 			return
 		}
 
-		// If this is an SSL certificate verification, immediately stop execution
-		if slashCommandBody.SSLCheck == "1" {
+		logger.Debug("received command", zap.Object("request", slashCommandBody))
+
+		// Drop Slack's retries of commands that were already delivered
+		retry := readRetry(r.Header)
+		if opts.retries.duplicate(slashCommandBody.TriggerID, retry) {
+			logger.Info("dropping retry of a delivered command", zap.String("triggerID", slashCommandBody.TriggerID), zap.Int("retryNum", retry.Num), zap.String("retryReason", retry.Reason))
 			return
 		}
 
-		// Split the command text into command and arguments
-		commandTextSplit := strings.Split(slashCommandBody.Text, " ")
-		command := "help"
-		if len(commandTextSplit) > 0 {
-			command = commandTextSplit[0]
+		// Ensure the command isn't too long to be worth parsing
+		if reason := textLimitExceeded(opts, slashCommandBody); len(reason) > 0 {
+			respondOverLimit(logger, opts.responder, slashCommandBody.ResponseURL, reason)
+			return
 		}
-		commandArguments := []string{}
-		if len(commandTextSplit) > 1 {
-			commandArguments = commandTextSplit[1:]
+
+		// Identify the command, ensuring it doesn't have too many arguments
+		handler, commandArguments := route(currentHandlers(), slashCommandBody, opts)
+		if handler == nil {
+			return
 		}
+		if reason := argumentLimitExceeded(opts, commandArguments); len(reason) > 0 {
+			respondOverLimit(logger, opts.responder, slashCommandBody.ResponseURL, reason)
+			return
+		}
+		span.SetAttributes(commandAttribute.String(handler.CommandName()))
 
-		// Identify and handle the command
-		var response *SlackResponse
-		for _, handler := range handlers {
-			if handler.CommandName() == command {
-				response, err = handler.Handle(commandArguments, slashCommandBody)
-				if err != nil {
-					response = &SlackResponse{
-						ResponseType: "ephemeral",
-						Text:         err.Error(),
-					}
-				}
-				err = Respond(slashCommandBody.ResponseURL, response)
-				if err != nil {
-					logger.Error("could not send error message", zap.Error(err))
-				}
-				break
-			}
+		// Ask for confirmation before running destructive commands, they
+		// run once the user clicks Confirm
+		if promptForConfirmation(ctx, logger, opts, handler, commandArguments, slashCommandBody) {
+			return
+		}
+
+		// Handle the command within Slack's acknowledgement window, which
+		// starts from the request timestamp. The command is deferred to the
+		// background if the handler asks for it or the window has already
+		// passed.
+		deadline := givenTime.Add(acknowledgementWindow)
+		deferredHandler, ok := handler.(SlackSlashCommandDeferredHandler)
+		if (ok && deferredHandler.Deferred()) || time.Now().After(deadline) {
+			dispatchInBackground(withForm(withRetry(trace.ContextWithSpan(context.Background(), span), retry), undecodedForm), logger, handler, commandArguments, slashCommandBody, opts, func(cancelHint string) {
+				acknowledge(logger, w, handler, commandArguments, slashCommandBody, cancelHint)
+			})
+		} else {
+			ctx, cancel := context.WithDeadline(withForm(withRetry(ctx, retry), undecodedForm), deadline)
+			defer cancel()
+			dispatch(ctx, logger, opts, handler, commandArguments, slashCommandBody)
+		}
+	}
+}
+
+// verifySignedRequest verifies the request's signature and timestamp,
+// returning its body and the time Slack sent it
+func verifySignedRequest(logger *zap.Logger, w http.ResponseWriter, r *http.Request, signingKey string) ([]byte, time.Time, bool) {
+	// Ensure the request includes a signature header
+	signatureHeader := headerValue(r.Header, SignatureHeaderName)
+	if len(signatureHeader) == 0 {
+		logger.Error("missing request x-slack-signature-header")
+		return nil, time.Time{}, false
+	}
+
+	// Ensure the request includes a timestamp header
+	timestampHeader := headerValue(r.Header, TimestampHeaderName)
+	if len(timestampHeader) == 0 {
+		logger.Error("missing request x-slack-request-timestamp header")
+		return nil, time.Time{}, false
+	}
+
+	// Ensure the timestamp is numeric before it is used anywhere,
+	// including the signature base string
+	if !isUnixTimestamp(timestampHeader) {
+		logger.Error("timestamp header is not numeric", zap.String("timestamp", timestampHeader))
+		http.Error(w, "invalid timestamp", http.StatusBadRequest)
+		return nil, time.Time{}, false
+	}
+
+	// Verify that timestamp is within +/- 5 minutes from now to prevent replay attacks
+	timestampHeaderInt, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		logger.Error("timestamp header could not be converted to a UNIX epoch", zap.Error(err))
+		return nil, time.Time{}, false
+	}
+	givenTime := time.Unix(timestampHeaderInt, 0)
+	timeDiffInSeconds := time.Since(givenTime).Abs().Seconds()
+	if timeDiffInSeconds > 300 {
+		logger.Error("timestamp header is not within five minutes of current timestamp")
+		return nil, time.Time{}, false
+	}
+
+	// Generate a string of the request body, decompressing it if an
+	// intermediary has gzipped it since Slack signs the original body
+	body, err := readBody(r)
+	if err != nil {
+		logger.Error("unable to parse request body", zap.Error(err))
+		http.Error(w, "unreadable body", http.StatusBadRequest)
+		return nil, time.Time{}, false
+	}
+
+	// Compare the signature computed using the Slack signing key with
+	// the provided signature
+	if !VerifySignature(signingKey, timestampHeader, body, signatureHeader) {
+		logger.Error("computed signature and provided signature do not match", zap.String("provided", signatureHeader))
+		return nil, time.Time{}, false
+	}
+
+	return body, givenTime, true
+}
+
+// verifyTokenRequest verifies the request's legacy verification token,
+// returning its body and the time it was received since such requests
+// carry no timestamp. Slack has deprecated verification tokens in favour
+// of signatures.
+func verifyTokenRequest(logger *zap.Logger, w http.ResponseWriter, r *http.Request, verificationToken string) ([]byte, time.Time, bool) {
+	body, err := readBody(r)
+	if err != nil {
+		logger.Error("unable to parse request body", zap.Error(err))
+		http.Error(w, "unreadable body", http.StatusBadRequest)
+		return nil, time.Time{}, false
+	}
+
+	// Compare the token in the form with the configured one, ignoring any
+	// part of the form that can't be parsed
+	form, _ := url.ParseQuery(string(body))
+	token := form.Get("token")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(verificationToken)) != 1 {
+		logger.Error("verification token does not match")
+		return nil, time.Time{}, false
+	}
+
+	return body, time.Now(), true
+}
+
+// writeJSONError replies with the status and a JSON body describing the
+// error, for requests that aren't slash commands at all
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("content-type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// VerifySignature reports whether signature, as sent in the
+// X-Slack-Signature header, is the v0 signature of the timestamp and body
+// using signingKey. It is the single implementation of Slack's request
+// verification, and compares signatures in constant time.
+func VerifySignature(signingKey string, timestamp string, body []byte, signature string) bool {
+	mac := hmac.New(sha256.New, []byte(signingKey))
+	mac.Write([]byte(fmt.Sprintf("v0:%s:%s", timestamp, body)))
+	signatureComputed := "v0=" + hex.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(signatureComputed), []byte(signature))
+}
+
+func isAllowedMediaType(mediaType string, allowed []string) bool {
+	for _, allowedType := range allowed {
+		if strings.EqualFold(mediaType, allowedType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Decompressed bodies larger than this are rejected, slash command
+// payloads are far smaller
+const maxDecompressedBodySize = 1 << 20
+
+func readBody(r *http.Request) ([]byte, error) {
+	if !strings.EqualFold(strings.TrimSpace(r.Header.Get("content-encoding")), "gzip") {
+		return io.ReadAll(r.Body)
+	}
+
+	reader, err := gzip.NewReader(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gzip body: %w", err)
+	}
+	defer reader.Close()
+	body, err := io.ReadAll(io.LimitReader(reader, maxDecompressedBodySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("invalid gzip body: %w", err)
+	}
+	if len(body) > maxDecompressedBodySize {
+		return nil, fmt.Errorf("decompressed body exceeds %d bytes", maxDecompressedBodySize)
+	}
+
+	return body, nil
+}
+
+func route(handlers []SlackSlashCommandHandler, request SlackSlashCommandBody, opts slackBotOptions) (SlackSlashCommandHandler, []string) {
+	// Handlers keyed on the slash command itself take the whole text
+	for _, handler := range handlers {
+		if keyedHandler, ok := handler.(SlackSlashCommandKeyedHandler); ok && matchesCommand(keyedHandler.SlashCommand(), request.Command, opts.caseSensitiveCommands) {
+			return handler, strings.Fields(request.Text)
+		}
+	}
+
+	// Otherwise, split the command text into command and arguments,
+	// falling back to help when no command was given, and the command
+	// into its namespace, if any
+	command, commandArguments := opts.commandParser.ParseCommand(request.Text)
+	if len(command) == 0 {
+		command = opts.helpCommandName
+	}
+	namespace, command := splitNamespace(command, opts.namespaceSeparator)
+	for _, handler := range handlers {
+		if matchesCommand(namespaceOf(handler), namespace, opts.caseSensitiveCommands) && matchesCommand(handler.CommandName(), command, opts.caseSensitiveCommands) {
+			return unwrapNamespace(handler), commandArguments
+		}
+	}
+
+	return nil, nil
+}
+
+func dispatch(ctx context.Context, logger *zap.Logger, opts slackBotOptions, handler SlackSlashCommandHandler, arguments []string, request SlackSlashCommandBody) {
+	ctx, span := startSpan(ctx, "slack.dispatch", trace.WithAttributes(commandAttribute.String(handler.CommandName())))
+	defer span.End()
+
+	// Make the progress reporter and the conversation available to
+	// context-aware handlers
+	ctx = withProgressReporter(ctx, newResponseURLProgressReporter(logger, opts.responder, request.ResponseURL))
+	ctx = withConversation(ctx, opts.conversations, request)
+	ctx = withSlackAPI(ctx, opts.client)
+	ctx = withCaseSensitiveCommands(ctx, opts.caseSensitiveCommands)
+	ctx, queued := withFollowups(ctx)
+
+	// Remember the command for the user's history, except for looking at
+	// the history itself
+	if _, ok := handler.(HistoryHandler); opts.history != nil && !ok {
+		opts.history.record(handler, arguments, request)
+	}
+
+	// Run the handler, unless it is idempotent and an identical command
+	// already ran, and convert any error into an ephemeral response
+	response, duplicate, err := opts.idempotency.run(handler, arguments, request, func() (*SlackResponse, error) {
+		return invokeRecovering(ctx, logger, handler, arguments, request)
+	})
+	if duplicate {
+		logger.Info("answering a duplicate command with the response of the first", zap.String("command", handler.CommandName()))
+	}
+	if errors.Is(err, context.Canceled) && errors.Is(ctx.Err(), context.Canceled) {
+		response = &SlackResponse{ResponseType: "ephemeral", Text: "This command was cancelled"}
+	} else if expiring, ok := expiringError(err, opts); ok {
+		// Post errors that expire with the Web API so that they can be
+		// deleted, falling back to the response_url if that fails
+		postErr := postExpiringError(logger, opts.client, request.ChannelID, expiring)
+		if postErr == nil {
+			return
+		}
+		logger.Error("could not post expiring error", zap.Error(postErr))
+		response = errorResponse(err)
+	} else if err != nil {
+		response = errorResponse(err)
+	}
+
+	// Warn users of deprecated commands, even when the handler has
+	// nothing else to say
+	if note := deprecationNote(handler); len(note) > 0 {
+		if response == nil {
+			response = &SlackResponse{ResponseType: "ephemeral"}
+		}
+		response = decorate(response, note+"\n", "")
+	}
+
+	// Replace the loading message posted before a background handler ran
+	response = replaceLoadingMessage(ctx, response)
+
+	// A nil response means the handler has nothing to say
+	if response == nil {
+		return
+	}
+
+	response = decorate(response, opts.responsePrefix, opts.responseSuffix)
+
+	// Post responses meant for another channel there, telling the
+	// requester how it went instead
+	if len(response.Channel) > 0 {
+		replaceOriginal := response.ReplaceOriginal
+		response = postToChannel(logger, opts.client, response)
+		response.ReplaceOriginal = replaceOriginal
+	}
+
+	// Deliver the response even if the handler's context was cancelled
+	// or its deadline has passed, followed by any message it queued
+	respondCtx := context.WithoutCancel(ctx)
+	if deliverResponse(respondCtx, logger, opts, request, response) {
+		deliverFollowups(respondCtx, logger, opts, request, queued)
+	}
+}
+
+// deliverResponse delivers the response to the request's response_url,
+// dead-lettering it if that fails, and reports whether it was delivered
+func deliverResponse(ctx context.Context, logger *zap.Logger, opts slackBotOptions, request SlackSlashCommandBody, response *SlackResponse) bool {
+	ctx, span := startSpan(ctx, "slack.respond")
+	defer span.End()
+	err := opts.responder.Deliver(ctx, request.ResponseURL, response)
+	if err != nil {
+		failSpan(span, err)
+		logger.Error("could not send error message", zap.Error(err))
+		deadLetter(ctx, logger, opts.deadLetters, request, response, err)
+		return false
+	}
+
+	return true
+}
+
+// deprecationNote returns the note telling users which command replaces
+// a deprecated one, or an empty string if the handler isn't deprecated
+func deprecationNote(handler SlackSlashCommandHandler) string {
+	deprecatedHandler, ok := handler.(SlackSlashCommandDeprecatedHandler)
+	if !ok || len(deprecatedHandler.DeprecatedInFavorOf()) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("⚠️ this command is deprecated, use %s", deprecatedHandler.DeprecatedInFavorOf())
+}
+
+// acknowledge writes the handler's acknowledgement, if any, followed by
+// the message telling the user how to cancel the command, if it can be,
+// as an ephemeral message in the body of the response to Slack's request
+func acknowledge(logger *zap.Logger, w http.ResponseWriter, handler SlackSlashCommandHandler, arguments []string, request SlackSlashCommandBody, cancelHint string) {
+	lines := []string{}
+	if acknowledgingHandler, ok := handler.(SlackSlashCommandAcknowledgingHandler); ok {
+		if text := acknowledgingHandler.Acknowledgement(arguments, request); len(text) > 0 {
+			lines = append(lines, text)
+		}
+	}
+	if len(cancelHint) > 0 {
+		lines = append(lines, cancelHint)
+	}
+	if len(lines) == 0 {
+		return
+	}
+	text := strings.Join(lines, "\n")
+
+	w.Header().Set("content-type", "application/json; charset=utf-8")
+	err := json.NewEncoder(w).Encode(&SlackResponse{
+		ResponseType: "ephemeral",
+		Text:         text,
+	})
+	if err != nil {
+		logger.Error("could not send acknowledgement", zap.Error(err))
+	}
+}
+
+// invoke calls whichever of the handler's methods is the most specific
+func invoke(ctx context.Context, handler SlackSlashCommandHandler, arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	if structuredHandler, ok := handler.(SlackSlashCommandStructuredHandler); ok {
+		return structuredHandler.HandleCommand(ctx, newCommand(handler.CommandName(), arguments, request), request)
+	}
+	if contextHandler, ok := handler.(SlackSlashCommandContextHandler); ok {
+		return contextHandler.HandleContext(ctx, arguments, request)
+	}
+
+	return handler.Handle(arguments, request)
+}
+
+// ErrHandlerPanicked is shown to users when a handler panics
+var ErrHandlerPanicked = errors.New("internal error, the command failed unexpectedly")
+
+// invokeRecovering runs the handler like invoke, turning a panic into
+// ErrHandlerPanicked so that one handler can't crash the bot, and counts
+// the outcome of every invocation
+func invokeRecovering(ctx context.Context, logger *zap.Logger, handler SlackSlashCommandHandler, arguments []string, request SlackSlashCommandBody) (response *SlackResponse, err error) {
+	command := handler.CommandName()
+	span := trace.SpanFromContext(ctx)
+	defer func() {
+		recovered := recover()
+		if recovered != nil {
+			logger.Error("handler panicked", zap.String("command", command), zap.Any("panic", recovered), zap.Stack("stack"))
+			response, err = nil, ErrHandlerPanicked
+			handlerInvocations.WithLabelValues(command, "panic").Inc()
+			span.SetAttributes(outcomeAttribute.String("panic"))
+			failSpan(span, err)
+			return
+		}
+
+		outcome := "success"
+		if err != nil {
+			outcome = "error"
+			failSpan(span, err)
+		}
+		handlerInvocations.WithLabelValues(command, outcome).Inc()
+		span.SetAttributes(outcomeAttribute.String(outcome))
+	}()
+
+	return invoke(ctx, handler, arguments, request)
+}
+
+// CommandParser splits slash command text into the command and its
+// arguments, returning an empty command when there is none
+type CommandParser interface {
+	ParseCommand(text string) (string, []string)
+}
+
+// CommandParserFunc adapts a function to the CommandParser interface
+type CommandParserFunc func(text string) (string, []string)
+
+func (f CommandParserFunc) ParseCommand(text string) (string, []string) {
+	return f(text)
+}
+
+// ParseCommand splits slash command text into the command and its
+// arguments. The command is empty when the text doesn't contain one.
+func ParseCommand(text string) (string, []string) {
+	// Text made up only of whitespace contains no command at all
+	if len(strings.TrimSpace(text)) == 0 {
+		return "", []string{}
+	}
+	commandTextSplit := strings.Split(strings.TrimLeft(text, " "), " ")
+
+	// Drop a leading bot mention such as `@bot` or Slack's escaped `<@U123>`
+	if strings.HasPrefix(commandTextSplit[0], "@") || strings.HasPrefix(commandTextSplit[0], "<@") {
+		commandTextSplit = commandTextSplit[1:]
+		if len(commandTextSplit) == 0 {
+			return "", []string{}
+		}
+	}
+
+	// Drop a redundant slash in front of the command, as in `/echo`
+	command := strings.TrimPrefix(commandTextSplit[0], "/")
+
+	return command, commandTextSplit[1:]
+}
+
+func matchesCommand(commandName string, command string, caseSensitive bool) bool {
+	if caseSensitive {
+		return commandName == command
+	}
+
+	return strings.EqualFold(commandName, command)
+}
+
+// errorResponse converts a handler error into a response, visible only to
+// the requester unless the error is a HandlerError saying otherwise
+func errorResponse(err error) *SlackResponse {
+	responseType := "ephemeral"
+	var handlerError *HandlerError
+	if errors.As(err, &handlerError) && len(handlerError.ResponseType) > 0 {
+		responseType = handlerError.ResponseType
+	}
+
+	return &SlackResponse{
+		ResponseType: responseType,
+		Text:         err.Error(),
+	}
+}
+
+// expiringError returns the in-channel HandlerError within err that should
+// be deleted after a delay, if the bot can delete messages
+func expiringError(err error, opts slackBotOptions) (*HandlerError, bool) {
+	var handlerError *HandlerError
+	if opts.client == nil || !errors.As(err, &handlerError) {
+		return nil, false
+	}
+
+	return handlerError, handlerError.ResponseType == "in_channel" && handlerError.ExpireAfter > 0
+}
+
+// postExpiringError posts the error to the channel and schedules its
+// deletion once it expires
+func postExpiringError(logger *zap.Logger, client SlackAPI, channel string, handlerError *HandlerError) error {
+	ts, err := client.PostMessage(channel, handlerError.Error())
+	if err != nil {
+		return err
+	}
+
+	time.AfterFunc(handlerError.ExpireAfter, func() {
+		err := client.DeleteMessage(channel, ts)
+		if err != nil {
+			logger.Error("could not delete expired error", zap.String("channel", channel), zap.String("ts", ts), zap.Error(err))
 		}
+	})
+
+	return nil
+}
+
+// respondUnparseable lets the user know their command couldn't be decoded,
+// provided we could at least recover where to send the response
+func respondUnparseable(logger *zap.Logger, responder Responder, responseURL string) {
+	if len(responseURL) == 0 {
+		return
+	}
+
+	err := responder.Deliver(context.Background(), responseURL, &SlackResponse{
+		ResponseType: "ephemeral",
+		Text:         "I couldn't understand that command",
+	})
+	if err != nil {
+		logger.Error("could not send error message", zap.Error(err))
 	}
 }
 
+// Respond posts a response to a response_url with the HTTPResponder
 func Respond(responseURL string, responseBody *SlackResponse) error {
+	return HTTPResponder{}.Deliver(context.Background(), responseURL, responseBody)
+}
+
+// RespondSequence posts several responses to the same response_url in
+// order, such as a progress message followed by the final result, each
+// with its own response type. It stops at the first response that fails
+// to be delivered.
+func RespondSequence(responseURL string, responses []*SlackResponse) error {
+	for i, response := range responses {
+		err := Respond(responseURL, response)
+		if err != nil {
+			return fmt.Errorf("could not deliver response %d of %d: %w", i+1, len(responses), err)
+		}
+	}
+
+	return nil
+}
+
+func deliver(ctx context.Context, responseURL string, responseBody *SlackResponse) error {
 	// Convert response into string
 	responseString, err := json.Marshal(responseBody)
 	if err != nil {
@@ -197,19 +1037,28 @@ func Respond(responseURL string, responseBody *SlackResponse) error {
 	}
 
 	// Build response to Slack
-	request, err := http.NewRequest("POST", responseURL, bytes.NewBuffer(responseString))
+	request, err := http.NewRequestWithContext(ctx, "POST", responseURL, bytes.NewBuffer(responseString))
 	if err != nil {
 		return err
 	}
 	request.Header.Set("content-type", "application/json; charset=utf-8")
 
 	// Execute request
-	client := &http.Client{}
-	response, err := client.Do(request)
+	response, err := outboundClient.Do(request)
 	if err != nil {
 		return err
 	}
 	defer response.Body.Close()
 
+	// Ensure Slack accepted the response, it replies with a short reason
+	// in the body when it doesn't
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		reason, _ := io.ReadAll(io.LimitReader(response.Body, 256))
+		return &ResponseStatusError{
+			StatusCode: response.StatusCode,
+			Reason:     strings.TrimSpace(string(reason)),
+		}
+	}
+
 	return nil
 }
@@ -1,10 +1,109 @@
 package slack
 
 import (
-	"go.uber.org/zap"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
+type recordingHandler struct {
+	name      string
+	arguments *[]string
+}
+
+func (h recordingHandler) Handle(arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	*h.arguments = arguments
+	return &SlackResponse{
+		ResponseType: "in_channel",
+		Text:         h.name,
+	}, nil
+}
+
+func (h recordingHandler) CommandName() string {
+	return h.name
+}
+
+func (h recordingHandler) CommandArguments() string {
+	return ""
+}
+
+func (h recordingHandler) CommandDescription() string {
+	return ""
+}
+
+// newResponseServer starts a server standing in for a Slack response_url
+// and returns a channel receiving every response posted to it
+func newResponseServer(t *testing.T) (*httptest.Server, chan SlackResponse) {
+	responses := make(chan SlackResponse, 16)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response SlackResponse
+		err := json.NewDecoder(r.Body).Decode(&response)
+		if err != nil {
+			t.Errorf("could not decode response: %v", err)
+		}
+		responses <- response
+	}))
+	t.Cleanup(server.Close)
+
+	return server, responses
+}
+
+// newSignedRequest builds a slash command request signed the same way
+// Slack signs it
+func newSignedRequest(signingKey string, form url.Values) *http.Request {
+	return newSignedRequestWithBody(signingKey, form.Encode())
+}
+
+func newSignedRequestWithBody(signingKey string, body string) *http.Request {
+	return newSignedRequestAt(signingKey, body, time.Now())
+}
+
+func newSignedRequestAt(signingKey string, body string, at time.Time) *http.Request {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(signingKey))
+	mac.Write([]byte(fmt.Sprintf("v0:%s:%s", timestamp, body)))
+
+	r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	r.Header.Set("content-type", "application/x-www-form-urlencoded")
+	r.Header.Set("x-slack-request-timestamp", timestamp)
+	r.Header.Set("x-slack-signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+
+	return r
+}
+
+func receiveResponse(t *testing.T, responses chan SlackResponse) SlackResponse {
+	select {
+	case response := <-responses:
+		return response
+	case <-time.After(time.Second):
+		t.Fatalf("no response was delivered")
+	}
+
+	return SlackResponse{}
+}
+
 func TestHandlerWithLogger(t *testing.T) {
 	// Create structured logger
 	logger, err := zap.NewProduction()
@@ -13,5 +112,958 @@ func TestHandlerWithLogger(t *testing.T) {
 	}
 	defer logger.Sync()
 
-	_ = NewSlackBot(8080, "abc", []SlackSlashCommandHandler{})
+	_ = NewSlackBot(8080, NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{})
+}
+
+func TestCommandMatchingIgnoresCase(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}})
+
+	for _, command := range []string{"Echo", "ECHO", "echo"} {
+		r := newSignedRequest("abc", url.Values{
+			"text":         {command + " hi"},
+			"response_url": {server.URL},
+		})
+		handler(httptest.NewRecorder(), r)
+
+		response := receiveResponse(t, responses)
+		if response.Text != "echo" {
+			t.Errorf("command %q was not routed to echo", command)
+		}
+	}
+}
+
+func TestCommandMatchingCaseSensitive(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}}, WithCaseSensitiveCommands(true))
+
+	r := newSignedRequest("abc", url.Values{
+		"text":         {"Echo hi"},
+		"response_url": {server.URL},
+	})
+	handler(httptest.NewRecorder(), r)
+
+	select {
+	case response := <-responses:
+		t.Errorf("unexpected response %q for a case-mismatched command", response.Text)
+	default:
+	}
+}
+
+func TestParseCommandTrimsArtifacts(t *testing.T) {
+	for _, text := range []string{"@bot echo hi", "<@U0123> echo hi", "/echo hi"} {
+		command, arguments := ParseCommand(text)
+		if command != "echo" {
+			t.Errorf("text %q parsed to command %q, expected echo", text, command)
+		}
+		if len(arguments) != 1 || arguments[0] != "hi" {
+			t.Errorf("text %q parsed to arguments %v, expected [hi]", text, arguments)
+		}
+	}
+}
+
+func TestTrimmedCommandsRouteToHandler(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}})
+
+	for _, text := range []string{"@bot echo hi", "/echo hi"} {
+		r := newSignedRequest("abc", url.Values{
+			"text":         {text},
+			"response_url": {server.URL},
+		})
+		handler(httptest.NewRecorder(), r)
+
+		response := receiveResponse(t, responses)
+		if response.Text != "echo" {
+			t.Errorf("text %q was not routed to echo", text)
+		}
+		if len(arguments) != 1 || arguments[0] != "hi" {
+			t.Errorf("text %q was routed with arguments %v, expected [hi]", text, arguments)
+		}
+	}
+}
+
+type standupHandler struct {
+	recordingHandler
+}
+
+func (h standupHandler) SlashCommand() string {
+	return "/standup"
+}
+
+func TestCommandKeyedHandlerWithEmptyText(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var echoArguments, standupArguments []string
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{
+		recordingHandler{"echo", &echoArguments},
+		standupHandler{recordingHandler{"standup", &standupArguments}},
+	})
+
+	r := newSignedRequest("abc", url.Values{
+		"command":      {"/standup"},
+		"text":         {""},
+		"response_url": {server.URL},
+	})
+	handler(httptest.NewRecorder(), r)
+
+	response := receiveResponse(t, responses)
+	if response.Text != "standup" {
+		t.Errorf("empty /standup invocation was not routed to the standup handler")
+	}
+	if len(standupArguments) != 0 {
+		t.Errorf("expected no arguments, got %v", standupArguments)
+	}
+}
+
+func TestMalformedFormIsReportedToUser(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}})
+
+	r := newSignedRequestWithBody("abc", "response_url="+url.QueryEscape(server.URL)+"&text=echo%zz")
+	handler(httptest.NewRecorder(), r)
+
+	response := receiveResponse(t, responses)
+	if response.ResponseType != "ephemeral" || response.Text != "I couldn't understand that command" {
+		t.Errorf("unexpected response to a malformed form: %+v", response)
+	}
+}
+
+func TestEmptyTextShowsHelp(t *testing.T) {
+	server, responses := newResponseServer(t)
+	bot := NewSlackBot(8080, NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{})
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), *bot.handlers.Load(), bot.options...)
+
+	for _, text := range []string{"", "   ", "@bot"} {
+		r := newSignedRequest("abc", url.Values{
+			"text":         {text},
+			"response_url": {server.URL},
+		})
+		handler(httptest.NewRecorder(), r)
+
+		response := receiveResponse(t, responses)
+		if !strings.HasPrefix(response.Text, "help [command|page]") {
+			t.Errorf("text %q did not return help, got %q", text, response.Text)
+		}
+	}
+}
+
+func TestMultipleMounts(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var argumentsA, argumentsB []string
+	bot := NewSlackBot(8080, NewStaticSecretSource("root", ""), []SlackSlashCommandHandler{})
+	err := bot.Mount("/appA", NewStaticSecretSource("keyA", ""), []SlackSlashCommandHandler{recordingHandler{"a", &argumentsA}})
+	if err != nil {
+		t.Fatalf("could not mount appA: %v", err)
+	}
+	err = bot.Mount("/appB", NewStaticSecretSource("keyB", ""), []SlackSlashCommandHandler{recordingHandler{"b", &argumentsB}})
+	if err != nil {
+		t.Fatalf("could not mount appB: %v", err)
+	}
+	if bot.Mount("/appA", NewStaticSecretSource("keyC", ""), []SlackSlashCommandHandler{}) == nil {
+		t.Errorf("expected an error when mounting the same path twice")
+	}
+	mux := bot.buildMux(zap.NewNop())
+
+	tests := []struct {
+		path       string
+		signingKey string
+		command    string
+	}{
+		{"/appA", "keyA", "a"},
+		{"/appB", "keyB", "b"},
+	}
+	for _, test := range tests {
+		r := newSignedRequest(test.signingKey, url.Values{
+			"text":         {test.command},
+			"response_url": {server.URL},
+		})
+		r.URL.Path = test.path
+		mux.ServeHTTP(httptest.NewRecorder(), r)
+
+		response := receiveResponse(t, responses)
+		if response.Text != test.command {
+			t.Errorf("request to %s was routed to %q", test.path, response.Text)
+		}
+	}
+
+	// A request signed for one app must not be accepted by another
+	r := newSignedRequest("keyA", url.Values{
+		"text":         {"b"},
+		"response_url": {server.URL},
+	})
+	r.URL.Path = "/appB"
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+	select {
+	case response := <-responses:
+		t.Errorf("appB accepted a request signed with appA's key and responded %q", response.Text)
+	default:
+	}
+}
+
+type deadlineHandler struct {
+	recordingHandler
+	deadline chan time.Time
+}
+
+func (h deadlineHandler) HandleContext(ctx context.Context, arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		close(h.deadline)
+	} else {
+		h.deadline <- deadline
+	}
+
+	return nil, nil
+}
+
+func TestContextDeadlineFromRequestTimestamp(t *testing.T) {
+	handler := deadlineHandler{recordingHandler{name: "slow"}, make(chan time.Time, 1)}
+	httpHandler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{handler})
+
+	timestamp := time.Now().Add(-time.Second).Truncate(time.Second)
+	r := newSignedRequestAt("abc", url.Values{"text": {"slow"}}.Encode(), timestamp)
+	httpHandler(httptest.NewRecorder(), r)
+
+	deadline, ok := <-handler.deadline
+	if !ok {
+		t.Fatalf("handler context has no deadline")
+	}
+	if !deadline.Equal(timestamp.Add(3 * time.Second)) {
+		t.Errorf("expected deadline %v, got %v", timestamp.Add(3*time.Second), deadline)
+	}
+}
+
+func TestCustomCommandParser(t *testing.T) {
+	server, responses := newResponseServer(t)
+	commaParser := CommandParserFunc(func(text string) (string, []string) {
+		split := strings.Split(text, ",")
+		return strings.TrimSpace(split[0]), split[1:]
+	})
+	var arguments []string
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{recordingHandler{"deploy", &arguments}}, WithCommandParser(commaParser))
+
+	r := newSignedRequest("abc", url.Values{
+		"text":         {"deploy,svc a,svc b"},
+		"response_url": {server.URL},
+	})
+	handler(httptest.NewRecorder(), r)
+
+	if response := receiveResponse(t, responses); response.Text != "deploy" {
+		t.Errorf("text was routed to %q", response.Text)
+	}
+	if len(arguments) != 2 || arguments[0] != "svc a" || arguments[1] != "svc b" {
+		t.Errorf("unexpected arguments %v", arguments)
+	}
+}
+
+func TestEncodedFormValuesAreDecoded(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}})
+
+	r := newSignedRequestWithBody("abc", "response_url="+url.QueryEscape(server.URL)+"&text=echo+a%26b+c%3Dd+50%25")
+	handler(httptest.NewRecorder(), r)
+
+	receiveResponse(t, responses)
+	expected := []string{"a&b", "c=d", "50%"}
+	if strings.Join(arguments, "|") != strings.Join(expected, "|") {
+		t.Errorf("expected arguments %v, got %v", expected, arguments)
+	}
+}
+
+func TestReadinessGate(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	readiness := NewReadinessGate()
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}}, WithReadinessGate(readiness))
+	form := url.Values{
+		"text":         {"echo"},
+		"response_url": {server.URL},
+	}
+
+	w := httptest.NewRecorder()
+	handler(w, newSignedRequest("abc", form))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 before readiness, got %d", w.Code)
+	}
+
+	readiness.MarkReady()
+	w = httptest.NewRecorder()
+	handler(w, newSignedRequest("abc", form))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 once ready, got %d", w.Code)
+	}
+	if response := receiveResponse(t, responses); response.Text != "echo" {
+		t.Errorf("unexpected response %q", response.Text)
+	}
+}
+
+func TestGzippedBody(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}})
+	form := url.Values{
+		"text":         {"echo hi"},
+		"response_url": {server.URL},
+	}
+
+	// Slack signs the original body, the compression happens downstream
+	r := newSignedRequest("abc", form)
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	writer.Write([]byte(form.Encode()))
+	writer.Close()
+	r.Body = io.NopCloser(&compressed)
+	r.Header.Set("content-encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 for a gzipped body, got %d", w.Code)
+	}
+	if response := receiveResponse(t, responses); response.Text != "echo" {
+		t.Errorf("unexpected response %q", response.Text)
+	}
+	if len(arguments) != 1 || arguments[0] != "hi" {
+		t.Errorf("unexpected arguments %v", arguments)
+	}
+}
+
+func TestInvalidGzippedBody(t *testing.T) {
+	var arguments []string
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}})
+	r := newSignedRequestWithBody("abc", "text=echo")
+	r.Header.Set("content-encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a body that isn't gzipped, got %d", w.Code)
+	}
+	if arguments != nil {
+		t.Errorf("handler ran for an unreadable body")
+	}
+}
+
+func TestRespondSequence(t *testing.T) {
+	server, responses := newResponseServer(t)
+	sequence := []*SlackResponse{
+		{ResponseType: "ephemeral", Text: "working on it"},
+		{ResponseType: "in_channel", Text: "done"},
+		{ResponseType: "ephemeral", Text: "only you can see this"},
+	}
+
+	err := RespondSequence(server.URL, sequence)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expected := range sequence {
+		response := receiveResponse(t, responses)
+		if response.ResponseType != expected.ResponseType || response.Text != expected.Text {
+			t.Errorf("expected %q as %s, got %q as %s", expected.Text, expected.ResponseType, response.Text, response.ResponseType)
+		}
+	}
+}
+
+func TestRespondSequenceStopsOnFailure(t *testing.T) {
+	delivered := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered++
+		http.Error(w, "expired_url", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	err := RespondSequence(server.URL, []*SlackResponse{{Text: "first"}, {Text: "second"}})
+	var statusErr *ResponseStatusError
+	if !errors.As(err, &statusErr) {
+		t.Errorf("expected a ResponseStatusError, got %v", err)
+	}
+	if delivered != 1 {
+		t.Errorf("expected delivery to stop after the first failure, %d were attempted", delivered)
+	}
+}
+
+type silentHandler struct {
+	recordingHandler
+}
+
+func (h silentHandler) Handle(arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	*h.arguments = arguments
+	return nil, nil
+}
+
+func TestSilentHandlerPostsNoMessage(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{silentHandler{recordingHandler{"trigger", &arguments}}})
+	form := url.Values{
+		"text":         {"trigger now"},
+		"response_url": {server.URL},
+	}
+
+	w := httptest.NewRecorder()
+	handler(w, newSignedRequest("abc", form))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+	if len(arguments) != 1 || arguments[0] != "now" {
+		t.Errorf("silent handler didn't run, got arguments %v", arguments)
+	}
+	select {
+	case response := <-responses:
+		t.Errorf("unexpected response %q from a silent handler", response.Text)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestInvokeCommand(t *testing.T) {
+	var arguments []string
+	bot := NewSlackBot(0, NewStaticSecretSource("", ""), []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}})
+
+	response, err := bot.InvokeCommand(context.Background(), "ECHO hi", SlackSlashCommandBody{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.Text != "echo" || len(arguments) != 1 || arguments[0] != "hi" {
+		t.Errorf("unexpected response %q with arguments %v", response.Text, arguments)
+	}
+
+	_, err = bot.InvokeCommand(context.Background(), "missing", SlackSlashCommandBody{})
+	if err == nil {
+		t.Errorf("expected an error for a command with no handler")
+	}
+}
+
+type acknowledgingHandler struct {
+	recordingHandler
+}
+
+func (h acknowledgingHandler) Acknowledgement(arguments []string, request SlackSlashCommandBody) string {
+	return "on it, this may take a while"
+}
+
+func (h acknowledgingHandler) Deferred() bool {
+	return true
+}
+
+func TestAcknowledgementInResponseBody(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{acknowledgingHandler{recordingHandler{"report", &arguments}}})
+	form := url.Values{
+		"text":         {"report weekly"},
+		"response_url": {server.URL},
+	}
+
+	w := httptest.NewRecorder()
+	handler(w, newSignedRequest("abc", form))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+	if contentType := w.Header().Get("content-type"); !strings.HasPrefix(contentType, "application/json") {
+		t.Errorf("expected a JSON acknowledgement, got content-type %q", contentType)
+	}
+	var ack SlackResponse
+	err := json.NewDecoder(w.Body).Decode(&ack)
+	if err != nil {
+		t.Fatalf("could not decode acknowledgement: %v", err)
+	}
+	if ack.ResponseType != "ephemeral" || ack.Text != "on it, this may take a while" {
+		t.Errorf("unexpected acknowledgement %q as %s", ack.Text, ack.ResponseType)
+	}
+
+	if response := receiveResponse(t, responses); response.Text != "report" {
+		t.Errorf("unexpected follow-up %q", response.Text)
+	}
+}
+
+func TestContentTypeParameters(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}})
+	form := url.Values{
+		"text":         {"echo"},
+		"response_url": {server.URL},
+	}
+
+	r := newSignedRequest("abc", form)
+	r.Header.Set("content-type", "application/x-www-form-urlencoded; charset=utf-8")
+	handler(httptest.NewRecorder(), r)
+
+	if response := receiveResponse(t, responses); response.Text != "echo" {
+		t.Errorf("unexpected response %q", response.Text)
+	}
+}
+
+func TestDisallowedContentType(t *testing.T) {
+	var arguments []string
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}})
+
+	for _, contentType := range []string{"application/json", "not a media type;;", ""} {
+		r := newSignedRequestWithBody("abc", "text=echo")
+		r.Header.Set("content-type", contentType)
+		handler(httptest.NewRecorder(), r)
+	}
+	if arguments != nil {
+		t.Errorf("handler ran for a disallowed content-type")
+	}
+}
+
+func TestContentTypeIsCaseInsensitive(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	form := url.Values{
+		"text":         {"echo"},
+		"response_url": {server.URL},
+	}
+
+	tests := []struct {
+		contentType string
+		options     []SlackBotOption
+	}{
+		{"Application/X-WWW-Form-Urlencoded", nil},
+		{"APPLICATION/X-WWW-FORM-URLENCODED; Charset=UTF-8", nil},
+		{"application/x-www-form-urlencoded", []SlackBotOption{WithAllowedContentTypes("Application/X-WWW-Form-Urlencoded")}},
+	}
+	for _, test := range tests {
+		handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}}, test.options...)
+		r := newSignedRequest("abc", form)
+		r.Header.Set("content-type", test.contentType)
+		handler(httptest.NewRecorder(), r)
+
+		select {
+		case response := <-responses:
+			if response.Text != "echo" {
+				t.Errorf("%q: unexpected response %q", test.contentType, response.Text)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("content-type %q was rejected", test.contentType)
+		}
+	}
+}
+
+func TestWrongMethodIsNotAllowed(t *testing.T) {
+	bot := NewSlackBot(8080, NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{})
+	mux := bot.buildMux(zap.NewNop())
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", w.Code)
+	}
+	if allow := w.Header().Get("allow"); allow != "POST" {
+		t.Errorf("expected an Allow: POST header, got %q", allow)
+	}
+	var body map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil || body["error"] != "method not allowed" {
+		t.Errorf("expected a JSON error body, got %q (%v)", w.Body.String(), err)
+	}
+}
+
+func TestUnknownPathIsNotFound(t *testing.T) {
+	var arguments []string
+	bot := NewSlackBot(8080, NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}})
+	mux := bot.buildMux(zap.NewNop())
+
+	r := newSignedRequestWithBody("abc", "text=echo")
+	r.URL.Path = "/unknown"
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+	if contentType := w.Header().Get("content-type"); !strings.HasPrefix(contentType, "application/json") {
+		t.Errorf("expected a JSON error body, got content-type %q", contentType)
+	}
+	if arguments != nil {
+		t.Errorf("handler ran for an unknown path")
+	}
+}
+
+func TestListenOnUnixSocket(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	socketPath := filepath.Join(t.TempDir(), "bot.sock")
+	bot := NewSlackBot(0, NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}}, WithUnixSocket(socketPath))
+	served := make(chan error, 1)
+	go func() {
+		served <- bot.ListenAndServe(zap.NewNop())
+	}()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+		},
+	}}
+
+	// Retry until the bot is listening
+	var resp *http.Response
+	var err error
+	for i := 0; i < 100; i++ {
+		r := newSignedRequest("abc", url.Values{
+			"text":         {"echo"},
+			"response_url": {server.URL},
+		})
+		r.RequestURI = ""
+		r.URL, _ = url.Parse("http://unix/")
+		resp, err = client.Do(r)
+		if err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("could not reach the bot over its socket: %v", err)
+	}
+	resp.Body.Close()
+	if response := receiveResponse(t, responses); response.Text != "echo" {
+		t.Errorf("unexpected response %q", response.Text)
+	}
+
+	// The socket is removed once the bot shuts down
+	err = bot.Shutdown(context.Background())
+	if err != nil {
+		t.Errorf("could not shut down: %v", err)
+	}
+	if err := <-served; err != http.ErrServerClosed {
+		t.Errorf("unexpected error from ListenAndServe: %v", err)
+	}
+	if _, err := os.Stat(socketPath); !os.IsNotExist(err) {
+		t.Errorf("expected the socket to be removed, got %v", err)
+	}
+}
+
+type sleepingHandler struct {
+	recordingHandler
+	duration time.Duration
+}
+
+func (h sleepingHandler) Handle(arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	time.Sleep(h.duration)
+	return nil, nil
+}
+
+func TestRequestTimeout(t *testing.T) {
+	server, _ := newResponseServer(t)
+	var arguments []string
+	handlers := []SlackSlashCommandHandler{
+		sleepingHandler{recordingHandler{name: "slow"}, 500 * time.Millisecond},
+		acknowledgingHandler{recordingHandler{"report", &arguments}},
+	}
+	bot := NewSlackBot(8080, NewStaticSecretSource("abc", ""), handlers, WithRequestTimeout(50*time.Millisecond))
+	handler := bot.buildHandler(zap.NewNop())
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newSignedRequest("abc", url.Values{
+		"text":         {"slow"},
+		"response_url": {server.URL},
+	}))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 for a request exceeding the timeout, got %d", w.Code)
+	}
+
+	// Requests completing in time, including their acknowledgement, are
+	// passed through untouched
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, newSignedRequest("abc", url.Values{
+		"text":         {"report"},
+		"response_url": {server.URL},
+	}))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "on it") {
+		t.Errorf("expected the acknowledgement within the timeout, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestSSLCheck(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}})
+
+	// Nothing else in the form, not even a part that fails to parse, may
+	// stop the check from succeeding
+	bodies := []string{
+		"ssl_check=1&token=abc",
+		"ssl_check=1&token=abc&text=echo&response_url=" + url.QueryEscape(server.URL),
+		"ssl_check=1&token=abc&response_url=" + url.QueryEscape(server.URL) + "&broken=%zz",
+	}
+	for _, body := range bodies {
+		w := httptest.NewRecorder()
+		handler(w, newSignedRequestWithBody("abc", body))
+
+		if w.Code != http.StatusOK || w.Body.Len() != 0 {
+			t.Errorf("%q: expected an empty 200, got %d %q", body, w.Code, w.Body.String())
+		}
+	}
+	if arguments != nil {
+		t.Errorf("handler ran for an SSL check")
+	}
+	select {
+	case response := <-responses:
+		t.Errorf("unexpected response %q to an SSL check", response.Text)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestLegacyVerificationToken(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	handlers := []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}}
+
+	tests := []struct {
+		name       string
+		signingKey string
+		token      string
+		accepted   bool
+	}{
+		{"matching token", "", "legacy", true},
+		{"mismatched token", "", "wrong", false},
+		{"missing token", "", "", false},
+		{"token ignored when a signing key is configured", "abc", "legacy", false},
+	}
+	for _, test := range tests {
+		handler := BuildHandler(zap.NewNop(), NewStaticSecretSource(test.signingKey, ""), handlers, WithLegacyVerificationToken("legacy"))
+		form := url.Values{
+			"text":         {"echo"},
+			"response_url": {server.URL},
+		}
+		if len(test.token) > 0 {
+			form.Set("token", test.token)
+		}
+		r := httptest.NewRequest("POST", "/", strings.NewReader(form.Encode()))
+		r.Header.Set("content-type", "application/x-www-form-urlencoded")
+		handler(httptest.NewRecorder(), r)
+
+		select {
+		case response := <-responses:
+			if !test.accepted {
+				t.Errorf("%s: expected the request to be rejected, got %q", test.name, response.Text)
+			}
+		case <-time.After(100 * time.Millisecond):
+			if test.accepted {
+				t.Errorf("%s: expected the request to be accepted", test.name)
+			}
+		}
+	}
+}
+
+type requestRecordingHandler struct {
+	recordingHandler
+	requests chan SlackSlashCommandBody
+}
+
+func (h requestRecordingHandler) Handle(arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	h.requests <- request
+	return nil, nil
+}
+
+func TestTokenIsDecodedAndRedacted(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	handler := requestRecordingHandler{recordingHandler{name: "echo"}, make(chan SlackSlashCommandBody, 1)}
+	buildHandler := BuildHandler(zap.New(core), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{handler})
+
+	buildHandler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+		"text":  {"echo"},
+		"token": {"secret-token"},
+	}))
+
+	request := <-handler.requests
+	if request.Token != "secret-token" {
+		t.Errorf("expected the token to be decoded, got %q", request.Token)
+	}
+
+	// The token never appears in logs or formatted output
+	entries := logs.All()
+	if len(entries) == 0 {
+		t.Fatalf("expected the request to be logged")
+	}
+	for _, entry := range entries {
+		for key, value := range entry.ContextMap() {
+			if strings.Contains(fmt.Sprint(value), "secret-token") {
+				t.Errorf("token logged in %s of %q", key, entry.Message)
+			}
+		}
+	}
+	for _, format := range []string{"%v", "%+v", "%#v", "%s"} {
+		if formatted := fmt.Sprintf(format, request); strings.Contains(formatted, "secret-token") || !strings.Contains(formatted, "[redacted]") {
+			t.Errorf("expected %s to redact the token, got %s", format, formatted)
+		}
+	}
+}
+
+func TestPanicWhileHandlingRequestIsRecovered(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	panicking := true
+	parser := CommandParserFunc(func(text string) (string, []string) {
+		if panicking {
+			var split []string
+			return split[1], split
+		}
+		return ParseCommand(text)
+	})
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}}, WithCommandParser(parser))
+	form := url.Values{
+		"text":         {"echo"},
+		"response_url": {server.URL},
+	}
+
+	w := httptest.NewRecorder()
+	handler(w, newSignedRequest("abc", form))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500 after a panic, got %d", w.Code)
+	}
+
+	// The handler keeps serving requests
+	panicking = false
+	w = httptest.NewRecorder()
+	handler(w, newSignedRequest("abc", form))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 after recovering, got %d", w.Code)
+	}
+	if response := receiveResponse(t, responses); response.Text != "echo" {
+		t.Errorf("unexpected response %q", response.Text)
+	}
+}
+
+func TestSetHandlersSwapsHandlersAtRuntime(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var oldArguments, newArguments []string
+	slow := blockingHandler{recordingHandler{"old", &oldArguments}, make(chan struct{}), make(chan struct{})}
+	bot := NewSlackBot(0, NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{slow})
+	mux := bot.buildMux(zap.NewNop())
+	send := func(text string) {
+		mux.ServeHTTP(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+			"text":         {text},
+			"response_url": {server.URL},
+		}))
+	}
+
+	// Swap the handlers while a request is being handled by the old ones
+	done := make(chan struct{})
+	go func() {
+		send("old")
+		close(done)
+	}()
+	<-slow.started
+	bot.SetHandlers([]SlackSlashCommandHandler{recordingHandler{"new", &newArguments}})
+	close(slow.release)
+	<-done
+	if response := receiveResponse(t, responses); response.Text != "old" {
+		t.Errorf("expected the in-flight request to finish with the old handler, got %q", response.Text)
+	}
+
+	// New requests are routed to the new handlers only
+	send("new")
+	if response := receiveResponse(t, responses); response.Text != "new" {
+		t.Errorf("expected the new command to be routed, got %q", response.Text)
+	}
+	send("old")
+	select {
+	case response := <-responses:
+		t.Errorf("expected the removed command not to be routed, got %q", response.Text)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// The help and complete commands are derived from the new handlers
+	handlers := *bot.handlers.Load()
+	if len(handlers) != 3 || handlers[1].CommandName() != "help" || handlers[2].CommandName() != "complete" {
+		t.Fatalf("expected the new handler followed by help and complete, got %d handlers", len(handlers))
+	}
+	response, err := handlers[1].Handle([]string{}, SlackSlashCommandBody{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	help, _ := json.Marshal(response)
+	if !strings.Contains(string(help), "new") || strings.Contains(string(help), "old") {
+		t.Errorf("expected help to list only the new handlers, got %s", help)
+	}
+}
+
+func TestResponseDecorationOnEchoOutput(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}}, WithResponseDecoration("[staging] ", "\n_sent by the bot_"))
+
+	handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+		"text":         {"echo hi"},
+		"response_url": {server.URL},
+	}))
+
+	response := receiveResponse(t, responses)
+	if response.Text != "[staging] echo\n_sent by the bot_" {
+		t.Errorf("expected the decorated echo output, got %q", response.Text)
+	}
+}
+
+type deprecatedHandler struct {
+	recordingHandler
+}
+
+func (h deprecatedHandler) DeprecatedInFavorOf() string {
+	return "echo2"
+}
+
+func TestDeprecationNoteIsPrepended(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{deprecatedHandler{recordingHandler{"echo", &arguments}}})
+
+	handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+		"text":         {"echo hi"},
+		"response_url": {server.URL},
+	}))
+
+	response := receiveResponse(t, responses)
+	if response.Text != "⚠️ this command is deprecated, use echo2\necho" {
+		t.Errorf("expected the deprecation note before the echo output, got %q", response.Text)
+	}
+}
+
+func TestQueryParametersFillFieldsMissingFromTheBody(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}})
+
+	// The signature only covers the body, so query parameters don't
+	// affect verification
+	r := newSignedRequest("abc", url.Values{"text": {"echo from the body"}})
+	r.URL.RawQuery = url.Values{
+		"text":         {"echo from the query"},
+		"response_url": {server.URL},
+	}.Encode()
+	handler(httptest.NewRecorder(), r)
+
+	if response := receiveResponse(t, responses); response.Text != "echo" {
+		t.Errorf("unexpected response %q", response.Text)
+	}
+	if strings.Join(arguments, " ") != "from the body" {
+		t.Errorf("expected the body to take precedence over the query, got %q", arguments)
+	}
+}
+
+func TestNewSlackBotLogsRegisteredCommands(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	var arguments []string
+	handlers := []SlackSlashCommandHandler{
+		recordingHandler{"echo", &arguments},
+		hiddenHandler{describedHandler{"drop-database", "", "Drops the database"}},
+	}
+	NewSlackBot(0, NewStaticSecretSource("abc", ""), handlers, WithLogger(zap.New(core)))
+
+	registered := map[string]bool{}
+	for _, entry := range logs.FilterMessage("registered command").All() {
+		fields := entry.ContextMap()
+		registered[fields["command"].(string)] = fields["hidden"].(bool)
+	}
+	expected := map[string]bool{"echo": false, "drop-database": true, "help": false, "complete": false}
+	if !reflect.DeepEqual(registered, expected) {
+		t.Errorf("expected every command to be logged with its visibility, got %v", registered)
+	}
 }
@@ -0,0 +1,101 @@
+package slack
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker is open, slack appears to be degraded")
+
+const (
+	breakerClosed = iota
+	breakerHalfOpen
+	breakerOpen
+)
+
+// CircuitBreaker stops calls to a degraded dependency. After a number of
+// consecutive failures it opens, rejecting every call for a cooldown
+// period, then lets a single trial call through to test whether the
+// dependency has recovered before closing again.
+type CircuitBreaker struct {
+	name             string
+	failureThreshold int
+	cooldown         time.Duration
+	isFailure        func(error) bool
+	now              func() time.Time
+	lock             sync.Mutex
+	state            int
+	failures         int
+	openedAt         time.Time
+}
+
+// NewCircuitBreaker creates a breaker opening after failureThreshold
+// consecutive calls whose error satisfies isFailure
+func NewCircuitBreaker(name string, failureThreshold int, cooldown time.Duration, isFailure func(error) bool) *CircuitBreaker {
+	breaker := &CircuitBreaker{
+		name:             name,
+		failureThreshold: failureThreshold,
+		cooldown:         cooldown,
+		isFailure:        isFailure,
+		now:              time.Now,
+	}
+	breakerState.WithLabelValues(name).Set(breakerClosed)
+
+	return breaker
+}
+
+// Do runs f unless the breaker is open, in which case it returns
+// ErrCircuitOpen without calling f
+func (b *CircuitBreaker) Do(f func() error) error {
+	if !b.allow() {
+		return ErrCircuitOpen
+	}
+
+	err := f()
+	b.record(err != nil && b.isFailure(err))
+
+	return err
+}
+
+func (b *CircuitBreaker) allow() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		// Let a single trial call through once the cooldown has passed
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.setState(breakerHalfOpen)
+		return true
+	case breakerHalfOpen:
+		// A trial call is already in flight
+		return false
+	default:
+		return true
+	}
+}
+
+func (b *CircuitBreaker) record(failed bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !failed {
+		b.failures = 0
+		b.setState(breakerClosed)
+		return
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.failureThreshold {
+		b.openedAt = b.now()
+		b.setState(breakerOpen)
+	}
+}
+
+func (b *CircuitBreaker) setState(state int) {
+	b.state = state
+	breakerState.WithLabelValues(b.name).Set(float64(state))
+}
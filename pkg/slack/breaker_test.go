@@ -0,0 +1,75 @@
+package slack
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCircuitBreakerOpensAndRecovers(t *testing.T) {
+	errDegraded := errors.New("degraded")
+	now := time.Now()
+	breaker := NewCircuitBreaker("test", 3, time.Minute, func(err error) bool {
+		return errors.Is(err, errDegraded)
+	})
+	breaker.now = func() time.Time {
+		return now
+	}
+	calls := 0
+	failing := func() error {
+		calls++
+		return errDegraded
+	}
+
+	// Trip the breaker with consecutive failures
+	for i := 0; i < 3; i++ {
+		if err := breaker.Do(failing); !errors.Is(err, errDegraded) {
+			t.Fatalf("call %d returned %v before the breaker opened", i, err)
+		}
+	}
+	if state := testutil.ToFloat64(breakerState.WithLabelValues("test")); state != breakerOpen {
+		t.Errorf("expected the open state to be exported, got %v", state)
+	}
+
+	// Calls are rejected without running while open
+	if err := breaker.Do(failing); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected the breaker to reject calls while open, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls to run, %d did", calls)
+	}
+
+	// A failed trial after the cooldown opens the breaker again
+	now = now.Add(time.Minute)
+	if err := breaker.Do(failing); !errors.Is(err, errDegraded) {
+		t.Errorf("expected a trial call after the cooldown, got %v", err)
+	}
+	if err := breaker.Do(failing); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected the breaker to reopen after a failed trial, got %v", err)
+	}
+
+	// A successful trial closes it
+	now = now.Add(time.Minute)
+	if err := breaker.Do(func() error { return nil }); err != nil {
+		t.Errorf("expected a successful trial call, got %v", err)
+	}
+	if err := breaker.Do(func() error { return nil }); err != nil {
+		t.Errorf("expected the breaker to close after a successful trial, got %v", err)
+	}
+	if state := testutil.ToFloat64(breakerState.WithLabelValues("test")); state != breakerClosed {
+		t.Errorf("expected the closed state to be exported, got %v", state)
+	}
+}
+
+func TestCircuitBreakerIgnoresNonDegradationErrors(t *testing.T) {
+	breaker := NewCircuitBreaker("ignored", 1, time.Minute, isDegradationFailure)
+	expired := &ResponseStatusError{StatusCode: 404, Reason: "expired_url"}
+
+	for i := 0; i < 3; i++ {
+		if err := breaker.Do(func() error { return expired }); err != expired {
+			t.Errorf("expected an expired response_url not to open the breaker, got %v", err)
+		}
+	}
+}
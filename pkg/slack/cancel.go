@@ -0,0 +1,160 @@
+package slack
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// CancellationRegistry keeps track of deferred commands that are still
+// running so that the users who started them can cancel them with the
+// CancelHandler
+type CancellationRegistry struct {
+	lock        sync.Mutex
+	invocations map[string]cancellableInvocation
+}
+
+type cancellableInvocation struct {
+	userID string
+	cancel context.CancelFunc
+}
+
+func NewCancellationRegistry() *CancellationRegistry {
+	return &CancellationRegistry{
+		invocations: map[string]cancellableInvocation{},
+	}
+}
+
+// register derives a cancellable context for an invocation started by
+// userID, returning its ID and a function removing it once it completes
+func (r *CancellationRegistry) register(ctx context.Context, userID string) (context.Context, string, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	id := newInvocationID()
+	for _, taken := r.invocations[id]; taken; _, taken = r.invocations[id] {
+		id = newInvocationID()
+	}
+	r.invocations[id] = cancellableInvocation{userID, cancel}
+
+	return ctx, id, func() {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		delete(r.invocations, id)
+		cancel()
+	}
+}
+
+// Cancel cancels the context of the running invocation with the given ID,
+// which may only be done by the user who started it
+func (r *CancellationRegistry) Cancel(id string, userID string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	invocation, ok := r.invocations[id]
+	if !ok || invocation.userID != userID {
+		return fmt.Errorf("you have no running command with ID %s", id)
+	}
+	invocation.cancel()
+	delete(r.invocations, id)
+
+	return nil
+}
+
+func newInvocationID() string {
+	id := make([]byte, 4)
+	rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
+
+// dispatchInBackground runs the handler without waiting for it to
+// complete. Deferred handlers are registered for cancellation when a
+// registry is configured, and the user is told how to cancel them. When
+// acknowledge is given, it is called before the handler starts with the
+// message telling the user how to cancel the command, empty if it can't
+// be, so that it is shown in the acknowledgement of the command rather
+// than spending one of the response_url's posts.
+func dispatchInBackground(ctx context.Context, logger *zap.Logger, handler SlackSlashCommandHandler, arguments []string, request SlackSlashCommandBody, opts slackBotOptions, acknowledge func(cancelHint string)) {
+	deferredHandler, ok := handler.(SlackSlashCommandDeferredHandler)
+	if opts.cancellations == nil || !ok || !deferredHandler.Deferred() {
+		if acknowledge != nil {
+			acknowledge("")
+		}
+		go func() {
+			ctx := postLoadingMessage(ctx, logger, opts, handler, arguments, request)
+			dispatch(ctx, logger, opts, handler, arguments, request)
+		}()
+		return
+	}
+
+	ctx, id, done := opts.cancellations.register(ctx, request.UserID)
+	cancelHint := fmt.Sprintf("Working on it, use `%s %s %s` to cancel", request.Command, cancelCommandName, id)
+	if acknowledge != nil {
+		acknowledge(cancelHint)
+	}
+	go func() {
+		defer done()
+
+		// Commands acknowledged without a body are told how to cancel
+		// through the response_url instead
+		if acknowledge == nil {
+			err := opts.responder.Deliver(context.WithoutCancel(ctx), request.ResponseURL, &SlackResponse{
+				ResponseType: "ephemeral",
+				Text:         cancelHint,
+			})
+			if err != nil {
+				logger.Error("could not send cancellation ID", zap.Error(err))
+			}
+		}
+
+		logger := logger.With(zap.String("invocation", id))
+		ctx = postLoadingMessage(ctx, logger, opts, handler, arguments, request)
+		dispatch(ctx, logger, opts, handler, arguments, request)
+	}()
+}
+
+const cancelCommandName = "cancel"
+
+// CancelHandler cancels deferred commands registered with a
+// CancellationRegistry
+type CancelHandler struct {
+	registry *CancellationRegistry
+}
+
+func NewCancelHandler(registry *CancellationRegistry) SlackSlashCommandHandler {
+	return CancelHandler{registry}
+}
+
+func (h CancelHandler) Handle(arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	if len(arguments) != 1 {
+		return nil, fmt.Errorf("a single command ID is required\n%s", UsageString(h))
+	}
+
+	err := h.registry.Cancel(arguments[0], request.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SlackResponse{
+		ResponseType: "ephemeral",
+		Text:         fmt.Sprintf("Cancelled command %s", arguments[0]),
+	}, nil
+}
+
+func (h CancelHandler) CommandName() string {
+	return cancelCommandName
+}
+
+func (h CancelHandler) CommandArguments() string {
+	return "<id>"
+}
+
+func (h CancelHandler) CommandDescription() string {
+	return "Cancels a long-running command you started, using the ID shown when it started"
+}
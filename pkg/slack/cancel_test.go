@@ -0,0 +1,101 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type cancellableHandler struct {
+	recordingHandler
+	cancelled chan error
+}
+
+func (h cancellableHandler) HandleContext(ctx context.Context, arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	<-ctx.Done()
+	h.cancelled <- ctx.Err()
+	return nil, ctx.Err()
+}
+
+func (h cancellableHandler) Deferred() bool {
+	return true
+}
+
+func TestCancelDeferredCommand(t *testing.T) {
+	server, responses := newResponseServer(t)
+	registry := NewCancellationRegistry()
+	slow := cancellableHandler{recordingHandler{name: "slow"}, make(chan error, 1)}
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{slow, NewCancelHandler(registry)}, WithCancellationRegistry(registry))
+
+	// Start the command and pick the ID out of the acknowledgement
+	w := httptest.NewRecorder()
+	handler(w, newSignedRequest("abc", url.Values{
+		"command":      {"/bot"},
+		"text":         {"slow"},
+		"user_id":      {"U1"},
+		"response_url": {server.URL},
+	}))
+	var started SlackResponse
+	err := json.NewDecoder(w.Body).Decode(&started)
+	if err != nil {
+		t.Fatalf("expected the ID in the acknowledgement: %v", err)
+	}
+	fields := strings.Fields(strings.Trim(strings.SplitN(started.Text, "`", 3)[1], "`"))
+	if len(fields) != 3 || fields[0] != "/bot" || fields[1] != "cancel" {
+		t.Fatalf("unexpected start message %q", started.Text)
+	}
+	id := fields[2]
+
+	// Another user can't cancel it
+	handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+		"text":         {"cancel " + id},
+		"user_id":      {"U2"},
+		"response_url": {server.URL},
+	}))
+	if response := receiveResponse(t, responses); !strings.Contains(response.Text, "no running command") {
+		t.Errorf("expected another user's cancellation to be refused, got %q", response.Text)
+	}
+
+	// The user who started it can
+	handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+		"text":         {"cancel " + id},
+		"user_id":      {"U1"},
+		"response_url": {server.URL},
+	}))
+	select {
+	case err := <-slow.cancelled:
+		if err != context.Canceled {
+			t.Errorf("expected the handler's context to be cancelled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("handler was not cancelled")
+	}
+
+	// Both the cancel command and the cancelled command reply
+	texts := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		texts[receiveResponse(t, responses).Text] = true
+	}
+	if !texts["Cancelled command "+id] || !texts["This command was cancelled"] {
+		t.Errorf("unexpected responses %v", texts)
+	}
+}
+
+func TestCompletedCommandsAreUnregistered(t *testing.T) {
+	registry := NewCancellationRegistry()
+	ctx, id, done := registry.register(context.Background(), "U1")
+	done()
+
+	if err := registry.Cancel(id, "U1"); err == nil {
+		t.Errorf("expected the completed command to be removed from the registry")
+	}
+	if ctx.Err() == nil {
+		t.Errorf("expected the completed command's context to be released")
+	}
+}
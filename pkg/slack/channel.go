@@ -0,0 +1,52 @@
+package slack
+
+import (
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// postToChannel posts the response to its channel with the Web API,
+// returning the ephemeral message telling the requester whether it was
+// posted
+func postToChannel(logger *zap.Logger, client SlackAPI, response *SlackResponse) *SlackResponse {
+	channel := response.Channel
+	notInChannel := &SlackResponse{
+		ResponseType: "ephemeral",
+		Text:         fmt.Sprintf("I can't post to <#%s> since I'm not a member, invite me with `/invite` first", channel),
+	}
+	if client == nil {
+		logger.Error("can't post a response to another channel without a Web API client", zap.String("channel", channel))
+		return &SlackResponse{
+			ResponseType: "ephemeral",
+			Text:         fmt.Sprintf("I can't post to <#%s> without a bot token", channel),
+		}
+	}
+
+	// Ensure the bot can post to the channel before trying
+	member, err := client.IsMember(channel)
+	if err != nil {
+		logger.Error("could not check membership of the response's channel", zap.String("channel", channel), zap.Error(err))
+	} else if !member {
+		return notInChannel
+	}
+
+	_, err = client.PostBlocks(channel, response.Text, response.Blocks)
+	var apiError *APIError
+	if errors.As(err, &apiError) && apiError.Code == "not_in_channel" {
+		return notInChannel
+	}
+	if err != nil {
+		logger.Error("could not post response to another channel", zap.String("channel", channel), zap.Error(err))
+		return &SlackResponse{
+			ResponseType: "ephemeral",
+			Text:         fmt.Sprintf("I couldn't post to <#%s>: %s", channel, err),
+		}
+	}
+
+	return &SlackResponse{
+		ResponseType: "ephemeral",
+		Text:         fmt.Sprintf("Posted to <#%s>", channel),
+	}
+}
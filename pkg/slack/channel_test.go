@@ -0,0 +1,103 @@
+package slack
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+type alertHandler struct {
+	recordingHandler
+}
+
+func (h alertHandler) Handle(arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	return &SlackResponse{ResponseType: "in_channel", Text: "disk is full", Channel: "CALERTS"}, nil
+}
+
+// newChannelAPIServer answers conversations.info with the given
+// membership and chat.postMessage with reply, recording posted messages
+func newChannelAPIServer(t *testing.T, member bool, reply string, posts chan map[string]interface{}) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/conversations.info":
+			if channel := r.FormValue("channel"); channel != "CALERTS" {
+				t.Errorf("expected the membership of CALERTS to be checked, got %q", channel)
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "channel": map[string]interface{}{"is_member": member}})
+		case "/chat.postMessage":
+			params := map[string]interface{}{}
+			json.NewDecoder(r.Body).Decode(&params)
+			posts <- params
+			w.Write([]byte(reply))
+		default:
+			t.Errorf("unexpected method %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestResponsesArePostedToTheirChannel(t *testing.T) {
+	posts := make(chan map[string]interface{}, 1)
+	api := newChannelAPIServer(t, true, `{"ok":true,"ts":"1.2"}`, posts)
+	client := NewClient("xoxb-test")
+	client.apiURL = api.URL + "/"
+	server, responses := newResponseServer(t)
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{alertHandler{recordingHandler{name: "alert"}}}, WithWebAPIClient(client))
+
+	handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+		"text":         {"alert"},
+		"channel_id":   {"C1"},
+		"response_url": {server.URL},
+	}))
+
+	params := <-posts
+	if params["channel"] != "CALERTS" || params["text"] != "disk is full" {
+		t.Errorf("expected the response to be posted to CALERTS, got %v", params)
+	}
+	if response := receiveResponse(t, responses); response.ResponseType != "ephemeral" || response.Text != "Posted to <#CALERTS>" {
+		t.Errorf("expected the requester to be told where it was posted, got %+v", response)
+	}
+}
+
+func TestResponsesForChannelsWithoutTheBotAreRejected(t *testing.T) {
+	posts := make(chan map[string]interface{}, 1)
+	api := newChannelAPIServer(t, false, `{"ok":false,"error":"not_in_channel"}`, posts)
+	client := NewClient("xoxb-test")
+	client.apiURL = api.URL + "/"
+	server, responses := newResponseServer(t)
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{alertHandler{recordingHandler{name: "alert"}}}, WithWebAPIClient(client))
+
+	handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+		"text":         {"alert"},
+		"response_url": {server.URL},
+	}))
+
+	response := receiveResponse(t, responses)
+	if response.ResponseType != "ephemeral" || response.Text != "I can't post to <#CALERTS> since I'm not a member, invite me with `/invite` first" {
+		t.Errorf("expected the requester to be told the bot isn't in the channel, got %+v", response)
+	}
+	select {
+	case params := <-posts:
+		t.Errorf("expected nothing to be posted, got %v", params)
+	default:
+	}
+}
+
+func TestNotInChannelErrorsAreReported(t *testing.T) {
+	posts := make(chan map[string]interface{}, 1)
+	api := newChannelAPIServer(t, true, `{"ok":false,"error":"not_in_channel"}`, posts)
+	client := NewClient("xoxb-test")
+	client.apiURL = api.URL + "/"
+
+	response := postToChannel(zap.NewNop(), client, &SlackResponse{Text: "disk is full", Channel: "CALERTS"})
+	<-posts
+	if response.Text != "I can't post to <#CALERTS> since I'm not a member, invite me with `/invite` first" {
+		t.Errorf("expected not_in_channel to be reported, got %q", response.Text)
+	}
+}
@@ -0,0 +1,310 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Slack refuses to schedule messages further ahead than this
+const maxScheduleAhead = 120 * 24 * time.Hour
+
+// Stops calling the Web API for a while when Slack appears to be degraded
+var apiBreaker = NewCircuitBreaker("web_api", 5, 30*time.Second, isDegradationFailure)
+
+// Client calls Slack Web API methods using a bot token
+type Client struct {
+	secrets SecretSource
+	apiURL  string
+}
+
+type apiResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+type scheduleMessageRequest struct {
+	Channel string `json:"channel"`
+	PostAt  int64  `json:"post_at"`
+	Text    string `json:"text"`
+}
+
+type scheduleMessageResponse struct {
+	apiResponse
+	ScheduledMessageID string `json:"scheduled_message_id"`
+}
+
+// NewClient creates a Web API client authenticating with the given bot
+// token, which must have the scopes required by the methods called
+func NewClient(token string) *Client {
+	return NewClientWithSecrets(NewStaticSecretSource("", token))
+}
+
+// NewClientWithSecrets creates a Web API client asking secrets for the
+// bot token on every call, so that rotated tokens are used right away
+func NewClientWithSecrets(secrets SecretSource) *Client {
+	return &Client{
+		secrets: secrets,
+		apiURL:  defaultSlackAPIURL,
+	}
+}
+
+// ScheduleMessage schedules text to be posted to channel at postAt, which
+// must be in the future and no more than 120 days away. It returns the ID
+// Slack assigned to the scheduled message.
+func (c *Client) ScheduleMessage(channel string, postAt time.Time, text string) (string, error) {
+	// Ensure Slack will accept the time
+	untilPost := time.Until(postAt)
+	if untilPost <= 0 {
+		return "", errors.New("messages can only be scheduled in the future")
+	}
+	if untilPost > maxScheduleAhead {
+		return "", fmt.Errorf("messages can't be scheduled more than %d days ahead", int(maxScheduleAhead.Hours()/24))
+	}
+
+	var response scheduleMessageResponse
+	err := c.call(context.Background(), "chat.scheduleMessage", scheduleMessageRequest{
+		Channel: channel,
+		PostAt:  postAt.Unix(),
+		Text:    text,
+	}, &response)
+	if err != nil {
+		return "", err
+	}
+
+	return response.ScheduledMessageID, nil
+}
+
+type postMessageRequest struct {
+	Channel string  `json:"channel"`
+	Text    string  `json:"text"`
+	Blocks  []Block `json:"blocks,omitempty"`
+}
+
+type postMessageResponse struct {
+	apiResponse
+	TS string `json:"ts"`
+}
+
+type deleteMessageRequest struct {
+	Channel string `json:"channel"`
+	TS      string `json:"ts"`
+}
+
+// PostMessage posts text to channel, returning the message's timestamp,
+// which identifies it in later calls such as DeleteMessage
+func (c *Client) PostMessage(channel string, text string) (string, error) {
+	return c.postMessage(channel, text, nil)
+}
+
+// PostBlocks posts Block Kit blocks to channel, with text as the fallback
+// for clients that can't render them, returning the message's timestamp
+func (c *Client) PostBlocks(channel string, text string, blocks []Block) (string, error) {
+	return c.postMessage(channel, text, blocks)
+}
+
+func (c *Client) postMessage(channel string, text string, blocks []Block) (string, error) {
+	var response postMessageResponse
+	err := c.call(context.Background(), "chat.postMessage", postMessageRequest{
+		Channel: channel,
+		Text:    text,
+		Blocks:  blocks,
+	}, &response)
+	if err != nil {
+		return "", err
+	}
+
+	return response.TS, nil
+}
+
+// DeleteMessage deletes the message with the given timestamp from channel
+func (c *Client) DeleteMessage(channel string, ts string) error {
+	var response apiResponse
+	return c.call(context.Background(), "chat.delete", deleteMessageRequest{
+		Channel: channel,
+		TS:      ts,
+	}, &response)
+}
+
+type addReactionRequest struct {
+	Channel   string `json:"channel"`
+	Timestamp string `json:"timestamp"`
+	Name      string `json:"name"`
+}
+
+// AddReaction reacts to the message with the given timestamp in channel
+// with emoji, named as in Slack such as "white_check_mark", with or
+// without the surrounding colons. It needs the reactions:write scope.
+func (c *Client) AddReaction(channel string, timestamp string, emoji string) error {
+	var response apiResponse
+	return c.call(context.Background(), "reactions.add", addReactionRequest{
+		Channel:   channel,
+		Timestamp: timestamp,
+		Name:      strings.Trim(emoji, ":"),
+	}, &response)
+}
+
+type conversationInfoResponse struct {
+	apiResponse
+	Channel struct {
+		IsMember bool `json:"is_member"`
+	} `json:"channel"`
+}
+
+// IsMember reports whether the bot is a member of channel, which it must
+// be to post to it. It needs the channels:read scope, and groups:read for
+// private channels.
+func (c *Client) IsMember(channel string) (bool, error) {
+	var response conversationInfoResponse
+	err := c.callForm(context.Background(), "conversations.info", url.Values{"channel": {channel}}, &response)
+	if err != nil {
+		return false, err
+	}
+
+	return response.Channel.IsMember, nil
+}
+
+// AuthTest checks that the Web API is reachable and accepts the token
+func (c *Client) AuthTest() error {
+	var response apiResponse
+	return c.call(context.Background(), "auth.test", struct{}{}, &response)
+}
+
+type getUploadURLResponse struct {
+	apiResponse
+	UploadURL string `json:"upload_url"`
+	FileID    string `json:"file_id"`
+}
+
+type uploadedFile struct {
+	ID    string `json:"id"`
+	Title string `json:"title,omitempty"`
+}
+
+// UploadFile shares content as a file named filename in channel,
+// returning the ID of the file. It uses Slack's external upload flow,
+// which replaces the deprecated files.upload: an upload URL is requested
+// with files.getUploadURLExternal, the content is posted to it, and the
+// upload is completed with files.completeUploadExternal.
+func (c *Client) UploadFile(channel string, filename string, content []byte) (string, error) {
+	// Ask for somewhere to upload the content to
+	var uploadURL getUploadURLResponse
+	err := c.callForm(context.Background(), "files.getUploadURLExternal", url.Values{
+		"filename": {filename},
+		"length":   {strconv.Itoa(len(content))},
+	}, &uploadURL)
+	if err != nil {
+		return "", err
+	}
+
+	// Upload the raw content
+	err = apiBreaker.Do(func() error {
+		request, err := http.NewRequestWithContext(context.Background(), "POST", uploadURL.UploadURL, bytes.NewReader(content))
+		if err != nil {
+			return err
+		}
+		request.Header.Set("content-type", "application/octet-stream")
+
+		response, err := outboundClient.Do(request)
+		if err != nil {
+			return err
+		}
+		defer response.Body.Close()
+		io.Copy(io.Discard, response.Body)
+		if response.StatusCode < 200 || response.StatusCode > 299 {
+			return fmt.Errorf("file upload failed: %w", &ResponseStatusError{StatusCode: response.StatusCode, Reason: response.Status})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Complete the upload, sharing the file in the channel
+	files, err := json.Marshal([]uploadedFile{{ID: uploadURL.FileID, Title: filename}})
+	if err != nil {
+		return "", err
+	}
+	var response apiResponse
+	err = c.callForm(context.Background(), "files.completeUploadExternal", url.Values{
+		"files":      {string(files)},
+		"channel_id": {channel},
+	}, &response)
+	if err != nil {
+		return "", err
+	}
+
+	return uploadURL.FileID, nil
+}
+
+// call posts params as JSON to the given Web API method and decodes the
+// reply into result, whose type must embed apiResponse
+func (c *Client) call(ctx context.Context, method string, params interface{}, result interface{ failure() string }) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	return c.do(ctx, method, "application/json; charset=utf-8", body, result)
+}
+
+// callForm is like call for the Web API methods that only accept their
+// params form encoded
+func (c *Client) callForm(ctx context.Context, method string, params url.Values, result interface{ failure() string }) error {
+	return c.do(ctx, method, "application/x-www-form-urlencoded", []byte(params.Encode()), result)
+}
+
+func (c *Client) do(ctx context.Context, method string, contentType string, body []byte, result interface{ failure() string }) error {
+	token, err := c.secrets.BotToken()
+	if err != nil {
+		return fmt.Errorf("could not read bot token: %w", err)
+	}
+
+	return apiBreaker.Do(func() error {
+		request, err := http.NewRequestWithContext(ctx, "POST", c.apiURL+method, bytes.NewBuffer(body))
+		if err != nil {
+			return err
+		}
+		request.Header.Set("content-type", contentType)
+		request.Header.Set("authorization", "Bearer "+token)
+
+		response, err := outboundClient.Do(request)
+		if err != nil {
+			return err
+		}
+		defer response.Body.Close()
+		if response.StatusCode < 200 || response.StatusCode > 299 {
+			return fmt.Errorf("%s failed: %w", method, &ResponseStatusError{StatusCode: response.StatusCode, Reason: response.Status})
+		}
+
+		err = json.NewDecoder(response.Body).Decode(result)
+		if err != nil {
+			return err
+		}
+		if failure := result.failure(); len(failure) > 0 {
+			return &APIError{Method: method, Code: failure}
+		}
+
+		return nil
+	})
+}
+
+func (r apiResponse) failure() string {
+	if r.OK {
+		return ""
+	}
+	if len(r.Error) == 0 {
+		return "unknown_error"
+	}
+
+	return r.Error
+}
@@ -0,0 +1,192 @@
+package slack
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+)
+
+func newAPIServer(t *testing.T, reply string, requests chan map[string]interface{}) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("authorization") != "Bearer xoxb-test" {
+			t.Errorf("unexpected authorization header %q", r.Header.Get("authorization"))
+		}
+		params := map[string]interface{}{"method": r.URL.Path}
+		err := json.NewDecoder(r.Body).Decode(&params)
+		if err != nil {
+			t.Errorf("could not decode request: %v", err)
+		}
+		requests <- params
+		w.Write([]byte(reply))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestScheduleMessage(t *testing.T) {
+	requests := make(chan map[string]interface{}, 1)
+	server := newAPIServer(t, `{"ok":true,"scheduled_message_id":"Q123"}`, requests)
+	client := NewClient("xoxb-test")
+	client.apiURL = server.URL + "/"
+	postAt := time.Now().Add(10 * time.Minute)
+
+	id, err := client.ScheduleMessage("C123", postAt, "standup")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != "Q123" {
+		t.Errorf("expected the scheduled message ID, got %q", id)
+	}
+
+	params := <-requests
+	if params["method"] != "/chat.scheduleMessage" {
+		t.Errorf("unexpected method %v", params["method"])
+	}
+	if params["post_at"] != float64(postAt.Unix()) {
+		t.Errorf("expected post_at %d, got %v", postAt.Unix(), params["post_at"])
+	}
+	if params["channel"] != "C123" || params["text"] != "standup" {
+		t.Errorf("unexpected params %v", params)
+	}
+}
+
+func TestScheduleMessageRejectsOutOfRangeTimes(t *testing.T) {
+	client := NewClient("xoxb-test")
+	client.apiURL = "http://127.0.0.1:0/"
+
+	for _, postAt := range []time.Time{time.Now().Add(-time.Minute), time.Now().Add(121 * 24 * time.Hour)} {
+		if _, err := client.ScheduleMessage("C123", postAt, "standup"); err == nil {
+			t.Errorf("expected an error scheduling at %v", postAt)
+		}
+	}
+}
+
+func TestScheduleMessageAPIError(t *testing.T) {
+	requests := make(chan map[string]interface{}, 1)
+	server := newAPIServer(t, `{"ok":false,"error":"channel_not_found"}`, requests)
+	client := NewClient("xoxb-test")
+	client.apiURL = server.URL + "/"
+
+	_, err := client.ScheduleMessage("C404", time.Now().Add(time.Hour), "standup")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "channel_not_found" {
+		t.Errorf("expected a channel_not_found APIError, got %v", err)
+	}
+}
+
+func TestAddReaction(t *testing.T) {
+	requests := make(chan map[string]interface{}, 1)
+	server := newAPIServer(t, `{"ok":true}`, requests)
+	client := NewClient("xoxb-test")
+	client.apiURL = server.URL + "/"
+
+	err := client.AddReaction("C123", "1700000000.000100", ":white_check_mark:")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	params := <-requests
+	if params["method"] != "/reactions.add" {
+		t.Errorf("unexpected method %v", params["method"])
+	}
+	if params["channel"] != "C123" || params["timestamp"] != "1700000000.000100" || params["name"] != "white_check_mark" {
+		t.Errorf("unexpected params %v", params)
+	}
+}
+
+func TestUploadFileUsesTheExternalUploadFlow(t *testing.T) {
+	var uploaded []byte
+	var completed url.Values
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/files.getUploadURLExternal":
+			r.ParseForm()
+			if r.Form.Get("filename") != "report.csv" || r.Form.Get("length") != "12" {
+				t.Errorf("unexpected upload URL request %v", r.Form)
+			}
+			w.Write([]byte(`{"ok":true,"upload_url":"` + server.URL + `/upload/F123","file_id":"F123"}`))
+		case "/upload/F123":
+			uploaded, _ = io.ReadAll(r.Body)
+		case "/files.completeUploadExternal":
+			r.ParseForm()
+			completed = r.Form
+			w.Write([]byte(`{"ok":true}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+	client := NewClient("xoxb-test")
+	client.apiURL = server.URL + "/"
+
+	id, err := client.UploadFile("C123", "report.csv", []byte("name,count\n\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != "F123" {
+		t.Errorf("expected the uploaded file's ID, got %q", id)
+	}
+	if string(uploaded) != "name,count\n\n" {
+		t.Errorf("unexpected uploaded content %q", uploaded)
+	}
+	if completed.Get("channel_id") != "C123" || completed.Get("files") != `[{"id":"F123","title":"report.csv"}]` {
+		t.Errorf("unexpected completion %v", completed)
+	}
+}
+
+func TestUploadFileReportsFailedUploads(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/files.getUploadURLExternal":
+			w.Write([]byte(`{"ok":true,"upload_url":"` + server.URL + `/upload/F123","file_id":"F123"}`))
+		case "/upload/F123":
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			t.Errorf("expected the upload not to be completed, got a request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+	client := NewClient("xoxb-test")
+	client.apiURL = server.URL + "/"
+
+	_, err := client.UploadFile("C123", "report.csv", []byte("data"))
+	var statusErr *ResponseStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected the failed upload to be reported, got %v", err)
+	}
+}
+
+func TestClientAsksForTheBotTokenOnEveryCall(t *testing.T) {
+	t.Setenv("TEST_BOT_TOKEN", "xoxb-first")
+	tokens := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens <- r.Header.Get("authorization")
+		w.Write([]byte(`{"ok":true,"ts":"1.2"}`))
+	}))
+	t.Cleanup(server.Close)
+	client := NewClientWithSecrets(NewEnvSecretSource("", "TEST_BOT_TOKEN"))
+	client.apiURL = server.URL + "/"
+
+	client.PostMessage("C123", "hello")
+	t.Setenv("TEST_BOT_TOKEN", "xoxb-second")
+	client.PostMessage("C123", "hello")
+
+	if first, second := <-tokens, <-tokens; first != "Bearer xoxb-first" || second != "Bearer xoxb-second" {
+		t.Errorf("expected the rotated token to be used, got %q then %q", first, second)
+	}
+
+	// Calls fail without reaching Slack when the token can't be read
+	os.Unsetenv("TEST_BOT_TOKEN")
+	if _, err := client.PostMessage("C123", "hello"); err == nil {
+		t.Errorf("expected an error when the bot token can't be read")
+	}
+}
@@ -0,0 +1,42 @@
+package slack
+
+import "context"
+
+// Command is a slash command parsed on behalf of a handler
+type Command struct {
+	// Name is the name of the handler the command was routed to
+	Name string
+	// Text is the raw text the user typed after the slash command
+	Text string
+	// Arguments are the arguments following the command name
+	Arguments []string
+	// Flags holds every `--name` or `--name=value` argument by name,
+	// handlers are responsible for rejecting flags they don't support
+	Flags map[string]string
+	// Positionals are the arguments that aren't flags
+	Positionals []string
+}
+
+// SlackSlashCommandStructuredHandler may be implemented by handlers that
+// would rather receive a parsed Command than split their arguments
+// themselves. HandleCommand is called instead of HandleContext and
+// Handle.
+type SlackSlashCommandStructuredHandler interface {
+	SlackSlashCommandHandler
+	HandleCommand(ctx context.Context, command Command, request SlackSlashCommandBody) (*SlackResponse, error)
+}
+
+func newCommand(name string, arguments []string, request SlackSlashCommandBody) Command {
+	// Every flag is accepted here, so parsing can't fail
+	flags, positionals, _ := parseFlags(arguments, func(string) bool {
+		return true
+	})
+
+	return Command{
+		Name:        name,
+		Text:        request.Text,
+		Arguments:   arguments,
+		Flags:       flags,
+		Positionals: positionals,
+	}
+}
@@ -0,0 +1,51 @@
+package slack
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+type commandRecordingHandler struct {
+	recordingHandler
+	command *Command
+}
+
+func (h commandRecordingHandler) HandleCommand(ctx context.Context, command Command, request SlackSlashCommandBody) (*SlackResponse, error) {
+	*h.command = command
+	return &SlackResponse{Text: h.name}, nil
+}
+
+func TestStructuredHandlerReceivesParsedCommand(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	var command Command
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{commandRecordingHandler{recordingHandler{"deploy", &arguments}, &command}})
+	form := url.Values{
+		"text":         {"deploy api --env=prod --force -- --literal"},
+		"response_url": {server.URL},
+	}
+
+	handler(httptest.NewRecorder(), newSignedRequest("abc", form))
+	if response := receiveResponse(t, responses); response.Text != "deploy" {
+		t.Errorf("unexpected response %q", response.Text)
+	}
+
+	if arguments != nil {
+		t.Errorf("Handle was called instead of HandleCommand")
+	}
+	expected := Command{
+		Name:        "deploy",
+		Text:        "deploy api --env=prod --force -- --literal",
+		Arguments:   []string{"api", "--env=prod", "--force", "--", "--literal"},
+		Flags:       map[string]string{"env": "prod", "force": ""},
+		Positionals: []string{"api", "--literal"},
+	}
+	if !reflect.DeepEqual(command, expected) {
+		t.Errorf("expected %+v, got %+v", expected, command)
+	}
+}
@@ -0,0 +1,112 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const completeCommandName = "complete"
+
+// SlackSlashCommandCompletingHandler may be implemented by handlers that
+// can suggest arguments as the user types them. ArgumentCompletions
+// receives the arguments typed so far, joined by spaces, and returns full
+// argument strings starting with them.
+type SlackSlashCommandCompletingHandler interface {
+	SlackSlashCommandHandler
+	ArgumentCompletions(partial string) []string
+}
+
+// CompletionHandler suggests completions for a partially typed command,
+// such as `complete echo he`, completing the command name itself until it
+// matches a command
+type CompletionHandler struct {
+	// help decides which commands the user can see
+	help HelpHandler
+}
+
+func NewCompletionHandler(handlers *[]SlackSlashCommandHandler) SlackSlashCommandHandler {
+	return CompletionHandler{newHelpHandler("", handlers, false, nil)}
+}
+
+func (c CompletionHandler) Handle(arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	return c.HandleContext(context.Background(), arguments, request)
+}
+
+func (c CompletionHandler) HandleContext(ctx context.Context, arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	partial := strings.Join(arguments, " ")
+	suggestions := c.complete(arguments, request.UserID, caseSensitiveCommandsFromContext(ctx))
+	if len(suggestions) == 0 {
+		return &SlackResponse{
+			ResponseType: "ephemeral",
+			Text:         fmt.Sprintf("No completions for `%s`", partial),
+		}, nil
+	}
+
+	lines := make([]string, len(suggestions))
+	for i, suggestion := range suggestions {
+		lines[i] = fmt.Sprintf("`%s`", suggestion)
+	}
+
+	return &SlackResponse{
+		ResponseType: "ephemeral",
+		Text:         strings.Join(lines, "\n"),
+	}, nil
+}
+
+// complete returns whole commands starting with the arguments, comparing
+// command names case sensitively if asked to
+func (c CompletionHandler) complete(arguments []string, userID string, caseSensitive bool) []string {
+	// Complete the arguments of the command once it is known
+	if len(arguments) > 0 {
+		for _, handler := range *c.help.handlers {
+			name := qualifiedName(handler, c.help.namespaceSeparator)
+			if !matchesCommand(name, arguments[0], caseSensitive) {
+				continue
+			}
+			completingHandler, ok := unwrapNamespace(handler).(SlackSlashCommandCompletingHandler)
+			if !ok {
+				return nil
+			}
+			suggestions := []string{}
+			for _, completion := range completingHandler.ArgumentCompletions(strings.Join(arguments[1:], " ")) {
+				suggestions = append(suggestions, name+" "+completion)
+			}
+			return suggestions
+		}
+	}
+
+	// Otherwise complete the command name among the visible commands
+	if len(arguments) > 1 {
+		return nil
+	}
+	prefix := ""
+	if len(arguments) == 1 {
+		prefix = arguments[0]
+	}
+	suggestions := []string{}
+	for _, handler := range c.help.visibleHandlers(userID) {
+		name := qualifiedName(handler, c.help.namespaceSeparator)
+		matches := strings.HasPrefix(name, prefix)
+		if !caseSensitive {
+			matches = strings.HasPrefix(strings.ToLower(name), strings.ToLower(prefix))
+		}
+		if matches {
+			suggestions = append(suggestions, name)
+		}
+	}
+
+	return suggestions
+}
+
+func (c CompletionHandler) CommandName() string {
+	return completeCommandName
+}
+
+func (c CompletionHandler) CommandArguments() string {
+	return "[command] [arguments]"
+}
+
+func (c CompletionHandler) CommandDescription() string {
+	return "Suggests ways to finish a partially typed command"
+}
@@ -0,0 +1,92 @@
+package slack
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// greetingHandler completes its argument from a fixed list of greetings
+type greetingHandler struct {
+	recordingHandler
+}
+
+func (h greetingHandler) ArgumentCompletions(partial string) []string {
+	completions := []string{}
+	for _, greeting := range []string{"hello", "hey", "howdy"} {
+		if strings.HasPrefix(greeting, partial) {
+			completions = append(completions, greeting)
+		}
+	}
+
+	return completions
+}
+
+func TestCompleteSuggestsArguments(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	handlers := withHelpHandler([]SlackSlashCommandHandler{greetingHandler{recordingHandler{"echo", &arguments}}}, nil)
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), handlers)
+
+	handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+		"text":         {"complete echo he"},
+		"response_url": {server.URL},
+	}))
+
+	response := receiveResponse(t, responses)
+	if response.ResponseType != "ephemeral" || response.Text != "`echo hello`\n`echo hey`" {
+		t.Errorf("expected the completions of he, got %+v", response)
+	}
+}
+
+func TestCompleteSuggestsCommandNames(t *testing.T) {
+	var arguments []string
+	handlers := []SlackSlashCommandHandler{
+		recordingHandler{"echo", &arguments},
+		recordingHandler{"edit", &arguments},
+		hiddenHandler{describedHandler{"erase", "", "Erases everything"}},
+		recordingHandler{"whoami", &arguments},
+	}
+	completion := NewCompletionHandler(&handlers)
+
+	response, err := completion.Handle([]string{"e"}, SlackSlashCommandBody{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.Text != "`echo`\n`edit`" {
+		t.Errorf("expected the visible commands starting with e, got %q", response.Text)
+	}
+
+	response, err = completion.Handle([]string{"whoami", "x"}, SlackSlashCommandBody{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.Text != "No completions for `whoami x`" {
+		t.Errorf("expected no completions for a command without any, got %q", response.Text)
+	}
+}
+
+func TestCompleteFollowsCaseSensitivity(t *testing.T) {
+	var arguments []string
+	handlers := []SlackSlashCommandHandler{greetingHandler{recordingHandler{"echo", &arguments}}}
+	completion := NewCompletionHandler(&handlers).(CompletionHandler)
+
+	response, err := completion.HandleContext(context.Background(), []string{"ECHO", "he"}, SlackSlashCommandBody{})
+	if err != nil || response.Text != "`echo hello`\n`echo hey`" {
+		t.Errorf("expected the completions regardless of case by default, got %+v, %v", response, err)
+	}
+
+	caseSensitive := withCaseSensitiveCommands(context.Background(), true)
+	response, err = completion.HandleContext(caseSensitive, []string{"ECHO", "he"}, SlackSlashCommandBody{})
+	if err != nil || response.Text != "No completions for `ECHO he`" {
+		t.Errorf("expected no completions for a command of another case when commands are case sensitive, got %+v, %v", response, err)
+	}
+	response, err = completion.HandleContext(caseSensitive, []string{"E"}, SlackSlashCommandBody{})
+	if err != nil || response.Text != "No completions for `E`" {
+		t.Errorf("expected no command names of another case when commands are case sensitive, got %+v, %v", response, err)
+	}
+}
@@ -0,0 +1,142 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const (
+	confirmActionID      = "slack_bot_confirm"
+	cancelActionID       = "slack_bot_cancel"
+	confirmationBlockID  = "slack_bot_confirmation"
+	confirmationQuestion = "Are you sure?"
+)
+
+// SlackSlashCommandConfirmingHandler may be implemented by handlers for
+// destructive commands, such as a deploy or a delete. When Confirmation
+// returns a question, the user is shown it with Confirm and Cancel
+// buttons instead of the command running, and the command only runs once
+// they click Confirm. Interactivity must be enabled in the Slack app, with
+// its request URL pointing at the bot.
+type SlackSlashCommandConfirmingHandler interface {
+	SlackSlashCommandHandler
+	Confirmation(arguments []string, request SlackSlashCommandBody) string
+}
+
+// pendingCommand is the command awaiting confirmation, carried in the
+// value of the Confirm button
+type pendingCommand struct {
+	Command string `json:"command"`
+	Text    string `json:"text"`
+}
+
+// NewConfirmationPrompt builds an ephemeral message asking question, with
+// Confirm and Cancel buttons. Clicking Confirm runs the command in the
+// request, skipping its confirmation. Commands too long to be carried by
+// the button are refused instead.
+func NewConfirmationPrompt(question string, request SlackSlashCommandBody) *SlackResponse {
+	if len(question) == 0 {
+		question = confirmationQuestion
+	}
+	value, _ := json.Marshal(pendingCommand{request.Command, request.Text})
+	if len(value) > maxButtonValueLength {
+		return &SlackResponse{
+			ResponseType: "ephemeral",
+			Text:         "That command is too long to be confirmed, shorten it and try again",
+		}
+	}
+	confirm := NewButtonElement(confirmActionID, "Confirm", string(value))
+	confirm.Style = "danger"
+
+	return &SlackResponse{
+		ResponseType: "ephemeral",
+		Text:         question,
+		Blocks: []Block{
+			NewSectionBlock(NewMarkdownText(question)),
+			NewActionsBlock(confirmationBlockID, confirm, NewButtonElement(cancelActionID, "Cancel", "")),
+		},
+	}
+}
+
+// promptForConfirmation asks the user to confirm the command if its
+// handler requires it, returning true if the command must not run yet
+func promptForConfirmation(ctx context.Context, logger *zap.Logger, opts slackBotOptions, handler SlackSlashCommandHandler, arguments []string, request SlackSlashCommandBody) bool {
+	confirmingHandler, ok := handler.(SlackSlashCommandConfirmingHandler)
+	if !ok {
+		return false
+	}
+	question := confirmingHandler.Confirmation(arguments, request)
+	if len(question) == 0 {
+		return false
+	}
+
+	err := opts.responder.Deliver(context.WithoutCancel(ctx), request.ResponseURL, NewConfirmationPrompt(question, request))
+	if err != nil {
+		logger.Error("could not send confirmation prompt", zap.Error(err))
+	}
+
+	return true
+}
+
+// handleInteraction runs or cancels the commands awaiting confirmation
+// when their buttons are clicked, other interactions are ignored
+func handleInteraction(ctx context.Context, logger *zap.Logger, opts slackBotOptions, handlers []SlackSlashCommandHandler, payload string) {
+	interaction, err := ParseBlockActions(payload)
+	if err != nil {
+		logger.Info("ignoring unsupported interaction", zap.Error(err))
+		return
+	}
+
+	for _, action := range interaction.Actions {
+		switch action.ActionID {
+		case cancelActionID:
+			replacePrompt(ctx, logger, opts, interaction, "Cancelled")
+		case confirmActionID:
+			var pending pendingCommand
+			err := json.Unmarshal([]byte(action.Value), &pending)
+			if err != nil {
+				logger.Error("unable to decode confirmed command", zap.Error(err))
+				continue
+			}
+
+			// Run the command as the user who confirmed it, responding to
+			// the interaction
+			request := SlackSlashCommandBody{
+				Command:     pending.Command,
+				Text:        pending.Text,
+				ResponseURL: interaction.ResponseURL,
+				TriggerID:   interaction.TriggerID,
+				UserID:      interaction.User.ID,
+				UserName:    interaction.User.Username,
+				TeamID:      interaction.User.TeamID,
+				ChannelID:   interaction.Channel.ID,
+				ChannelName: interaction.Channel.Name,
+			}
+			handler, commandArguments := route(handlers, request, opts)
+			if handler == nil {
+				replacePrompt(ctx, logger, opts, interaction, "This command is no longer available")
+				continue
+			}
+
+			// Replace the prompt first so that it can't be confirmed twice
+			replacePrompt(ctx, logger, opts, interaction, fmt.Sprintf("Confirmed, running `%s`", strings.ReplaceAll(pending.Text, "`", "'")))
+			dispatchInBackground(ctx, logger, handler, commandArguments, request, opts, nil)
+		}
+	}
+}
+
+// replacePrompt replaces the confirmation prompt with text
+func replacePrompt(ctx context.Context, logger *zap.Logger, opts slackBotOptions, interaction BlockActionsPayload, text string) {
+	err := opts.responder.Deliver(ctx, interaction.ResponseURL, &SlackResponse{
+		ResponseType:    "ephemeral",
+		Text:            text,
+		ReplaceOriginal: true,
+	})
+	if err != nil {
+		logger.Error("could not replace confirmation prompt", zap.Error(err))
+	}
+}
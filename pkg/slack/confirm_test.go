@@ -0,0 +1,135 @@
+package slack
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type confirmingHandler struct {
+	recordingHandler
+}
+
+func (h confirmingHandler) Confirmation(arguments []string, request SlackSlashCommandBody) string {
+	return "Really drop the database?"
+}
+
+// clickButton builds the block_actions payload Slack sends when the
+// button with actionID in the prompt is clicked
+func clickButton(t *testing.T, prompt SlackResponse, actionID string, responseURL string) string {
+	for _, block := range prompt.Blocks {
+		for _, element := range block.Elements {
+			button, ok := element.(map[string]interface{})
+			if !ok || button["action_id"] != actionID {
+				continue
+			}
+			value, _ := button["value"].(string)
+			payload, err := json.Marshal(BlockActionsPayload{
+				Type:        "block_actions",
+				ResponseURL: responseURL,
+				User:        InteractionUser{ID: "U1"},
+				Channel:     InteractionChannel{ID: "C1"},
+				Actions:     []BlockAction{{Type: "button", ActionID: actionID, BlockID: block.BlockID, Value: value}},
+			})
+			if err != nil {
+				t.Fatalf("could not encode interaction: %v", err)
+			}
+			return string(payload)
+		}
+	}
+	t.Fatalf("prompt has no %s button: %+v", actionID, prompt)
+
+	return ""
+}
+
+func TestConfirmingRunsThePendingCommand(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{confirmingHandler{recordingHandler{"drop", &arguments}}})
+
+	// The command asks for confirmation instead of running
+	handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+		"command":      {"/bot"},
+		"text":         {"drop users"},
+		"response_url": {server.URL},
+	}))
+	prompt := receiveResponse(t, responses)
+	if prompt.Text != "Really drop the database?" || arguments != nil {
+		t.Fatalf("expected a confirmation prompt without running the command, got %+v and arguments %q", prompt, arguments)
+	}
+
+	// Clicking Confirm replaces the prompt and runs the command
+	handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+		"payload": {clickButton(t, prompt, confirmActionID, server.URL)},
+	}))
+	if replaced := receiveResponse(t, responses); !replaced.ReplaceOriginal {
+		t.Errorf("expected the prompt to be replaced, got %+v", replaced)
+	}
+	if response := receiveResponse(t, responses); response.Text != "drop" {
+		t.Errorf("expected the confirmed command's response, got %q", response.Text)
+	}
+	if len(arguments) != 1 || arguments[0] != "users" {
+		t.Errorf("expected the command to run with its original arguments, got %q", arguments)
+	}
+}
+
+func TestCancellingDropsThePendingCommand(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{confirmingHandler{recordingHandler{"drop", &arguments}}})
+
+	handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+		"text":         {"drop users"},
+		"response_url": {server.URL},
+	}))
+	prompt := receiveResponse(t, responses)
+	handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+		"payload": {clickButton(t, prompt, cancelActionID, server.URL)},
+	}))
+
+	if response := receiveResponse(t, responses); response.Text != "Cancelled" {
+		t.Errorf("expected the prompt to be replaced with a cancellation, got %+v", response)
+	}
+	select {
+	case response := <-responses:
+		t.Errorf("expected the command not to run, got %+v", response)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestConfirmationPromptRefusesCommandsTooLongForItsButton(t *testing.T) {
+	prompt := NewConfirmationPrompt("", SlackSlashCommandBody{Command: "/bot", Text: "drop " + strings.Repeat("x", maxButtonValueLength)})
+	if len(prompt.Blocks) != 0 || !strings.Contains(prompt.Text, "too long") {
+		t.Errorf("expected the command to be refused, got %+v", prompt)
+	}
+
+	prompt = NewConfirmationPrompt("", SlackSlashCommandBody{Command: "/bot", Text: "drop users"})
+	if len(prompt.Blocks) == 0 || prompt.Text != confirmationQuestion {
+		t.Errorf("expected a confirmation prompt, got %+v", prompt)
+	}
+}
+
+func TestConfirmedCommandTextCannotBreakOutOfCode(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{confirmingHandler{recordingHandler{"drop", &arguments}}})
+
+	handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+		"text":         {"drop `<!channel>`"},
+		"response_url": {server.URL},
+	}))
+	prompt := receiveResponse(t, responses)
+	handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+		"payload": {clickButton(t, prompt, confirmActionID, server.URL)},
+	}))
+
+	if replaced := receiveResponse(t, responses); replaced.Text != "Confirmed, running `drop '<!channel>'`" {
+		t.Errorf("expected the backticks of the command to be neutralised, got %q", replaced.Text)
+	}
+	receiveResponse(t, responses)
+}
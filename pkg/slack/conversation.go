@@ -0,0 +1,136 @@
+package slack
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// How long conversations are remembered after they were last saved by
+// default
+const defaultConversationTTL = 15 * time.Minute
+
+// ConversationKey identifies a conversation by the user running the
+// commands and the channel and thread they are run in, so that users
+// never see each other's state. Slash commands only carry a thread_ts
+// when the source sending them adds one, so commands run outside a
+// thread share the user's conversation in the channel, with an empty
+// ThreadTS.
+type ConversationKey struct {
+	TeamID    string
+	UserID    string
+	ChannelID string
+	ThreadTS  string
+}
+
+// ConversationStore keeps the state of conversations between commands
+type ConversationStore interface {
+	// Load returns the state saved for the conversation, if it hasn't
+	// expired
+	Load(key ConversationKey) (interface{}, bool)
+	Save(key ConversationKey, state interface{})
+	Delete(key ConversationKey)
+}
+
+type conversationEntry struct {
+	state     interface{}
+	expiresAt time.Time
+}
+
+// MemoryConversationStore keeps conversations in memory, forgetting them
+// once their TTL has passed since they were last saved
+type MemoryConversationStore struct {
+	ttl     time.Duration
+	now     func() time.Time
+	lock    sync.Mutex
+	entries map[ConversationKey]conversationEntry
+}
+
+func NewMemoryConversationStore(ttl time.Duration) *MemoryConversationStore {
+	if ttl <= 0 {
+		ttl = defaultConversationTTL
+	}
+
+	return &MemoryConversationStore{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[ConversationKey]conversationEntry{},
+	}
+}
+
+func (s *MemoryConversationStore) Load(key ConversationKey) (interface{}, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || !s.now().Before(entry.expiresAt) {
+		delete(s.entries, key)
+		return nil, false
+	}
+
+	return entry.state, true
+}
+
+func (s *MemoryConversationStore) Save(key ConversationKey, state interface{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Forget expired conversations so that abandoned ones don't pile up
+	now := s.now()
+	for entryKey, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, entryKey)
+		}
+	}
+	s.entries[key] = conversationEntry{state, now.Add(s.ttl)}
+}
+
+func (s *MemoryConversationStore) Delete(key ConversationKey) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.entries, key)
+}
+
+// Conversation gives a handler the state of the conversation its
+// command is part of
+type Conversation struct {
+	store ConversationStore
+	key   ConversationKey
+}
+
+// State returns the state saved by an earlier command of the
+// conversation, if any
+func (c Conversation) State() (interface{}, bool) {
+	if c.store == nil {
+		return nil, false
+	}
+
+	return c.store.Load(c.key)
+}
+
+// Save replaces the state of the conversation for later commands
+func (c Conversation) Save(state interface{}) {
+	if c.store != nil {
+		c.store.Save(c.key, state)
+	}
+}
+
+// End forgets the conversation
+func (c Conversation) End() {
+	if c.store != nil {
+		c.store.Delete(c.key)
+	}
+}
+
+type conversationContextKey struct{}
+
+func withConversation(ctx context.Context, store ConversationStore, request SlackSlashCommandBody) context.Context {
+	return context.WithValue(ctx, conversationContextKey{}, Conversation{store, ConversationKey{request.TeamID, request.UserID, request.ChannelID, request.ThreadTS}})
+}
+
+// ConversationFromContext returns the conversation of the command being
+// handled, which keeps no state if there is none
+func ConversationFromContext(ctx context.Context) Conversation {
+	conversation, _ := ctx.Value(conversationContextKey{}).(Conversation)
+	return conversation
+}
@@ -0,0 +1,109 @@
+package slack
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// countingHandler counts the commands run in each conversation
+type countingHandler struct{}
+
+func (h countingHandler) Handle(arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	return h.HandleContext(context.Background(), arguments, request)
+}
+
+func (h countingHandler) HandleContext(ctx context.Context, arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	conversation := ConversationFromContext(ctx)
+	count := 0
+	if state, ok := conversation.State(); ok {
+		count = state.(int)
+	}
+	count++
+	conversation.Save(count)
+
+	return &SlackResponse{Text: strconv.Itoa(count)}, nil
+}
+
+func (h countingHandler) CommandName() string {
+	return "count"
+}
+
+func (h countingHandler) CommandArguments() string {
+	return ""
+}
+
+func (h countingHandler) CommandDescription() string {
+	return ""
+}
+
+func TestConversationStateIsKeptWithinAThread(t *testing.T) {
+	server, responses := newResponseServer(t)
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{countingHandler{}})
+	count := func(threadTS string) string {
+		handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+			"text":         {"count"},
+			"channel_id":   {"C1"},
+			"thread_ts":    {threadTS},
+			"response_url": {server.URL},
+		}))
+		return receiveResponse(t, responses).Text
+	}
+
+	if text := count("1700000000.000100"); text != "1" {
+		t.Errorf("expected the first command of the thread to count 1, got %q", text)
+	}
+	if text := count("1700000000.000100"); text != "2" {
+		t.Errorf("expected the second command of the thread to count 2, got %q", text)
+	}
+	if text := count("1700000000.000200"); text != "1" {
+		t.Errorf("expected another thread to start its own conversation, got %q", text)
+	}
+}
+
+func TestConversationStateIsKeptPerUser(t *testing.T) {
+	server, responses := newResponseServer(t)
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{countingHandler{}})
+	count := func(userID string) string {
+		handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+			"text":         {"count"},
+			"team_id":      {"T1"},
+			"channel_id":   {"C1"},
+			"user_id":      {userID},
+			"response_url": {server.URL},
+		}))
+		return receiveResponse(t, responses).Text
+	}
+
+	if text := count("U1"); text != "1" {
+		t.Errorf("expected the first command of U1 to count 1, got %q", text)
+	}
+	if text := count("U2"); text != "1" {
+		t.Errorf("expected U2 not to see the state of U1 in the same channel, got %q", text)
+	}
+	if text := count("U1"); text != "2" {
+		t.Errorf("expected U1 to keep their own state, got %q", text)
+	}
+}
+
+func TestConversationsExpireAfterTheirTTL(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryConversationStore(time.Minute)
+	store.now = func() time.Time { return now }
+	key := ConversationKey{"T1", "U1", "C1", "1700000000.000100"}
+
+	store.Save(key, "state")
+	now = now.Add(59 * time.Second)
+	if state, ok := store.Load(key); !ok || state != "state" {
+		t.Errorf("expected the conversation to be kept within its TTL, got %v", state)
+	}
+	now = now.Add(time.Second)
+	if _, ok := store.Load(key); ok {
+		t.Error("expected the conversation to expire after its TTL")
+	}
+}
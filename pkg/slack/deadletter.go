@@ -0,0 +1,184 @@
+package slack
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DeadLetterEntry records a response that could not be delivered, along
+// with the command it answered, so that it can be inspected or replayed
+type DeadLetterEntry struct {
+	Time      time.Time      `json:"time"`
+	Target    string         `json:"target"`
+	Response  *SlackResponse `json:"response"`
+	Error     string         `json:"error"`
+	Command   string         `json:"command,omitempty"`
+	Text      string         `json:"text,omitempty"`
+	UserID    string         `json:"user_id,omitempty"`
+	ChannelID string         `json:"channel_id,omitempty"`
+	TeamID    string         `json:"team_id,omitempty"`
+}
+
+// DeadLetterSink stores responses that could not be delivered
+type DeadLetterSink interface {
+	Record(ctx context.Context, entry DeadLetterEntry) error
+}
+
+// LogDeadLetterSink logs undelivered responses at warn level, it is used
+// unless another sink is set with WithDeadLetterSink
+type LogDeadLetterSink struct {
+	logger *zap.Logger
+}
+
+func NewLogDeadLetterSink(logger *zap.Logger) LogDeadLetterSink {
+	return LogDeadLetterSink{logger}
+}
+
+func (s LogDeadLetterSink) Record(ctx context.Context, entry DeadLetterEntry) error {
+	payload, err := json.Marshal(entry.Response)
+	if err != nil {
+		return err
+	}
+	s.logger.Warn("response could not be delivered",
+		zap.String("target", entry.Target),
+		zap.String("error", entry.Error),
+		zap.String("command", entry.Command),
+		zap.String("userID", entry.UserID),
+		zap.String("channelID", entry.ChannelID),
+		zap.ByteString("response", payload),
+	)
+
+	return nil
+}
+
+// FileDeadLetterSink appends undelivered responses to a file, one JSON
+// entry per line
+type FileDeadLetterSink struct {
+	path string
+	lock sync.Mutex
+}
+
+func NewFileDeadLetterSink(path string) *FileDeadLetterSink {
+	return &FileDeadLetterSink{path: path}
+}
+
+func (s *FileDeadLetterSink) Record(ctx context.Context, entry DeadLetterEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("could not open dead letter file: %w", err)
+	}
+	defer file.Close()
+	_, err = file.Write(append(line, '\n'))
+
+	return err
+}
+
+// deadLetter records a response that failed to be delivered for request
+func deadLetter(ctx context.Context, logger *zap.Logger, sink DeadLetterSink, request SlackSlashCommandBody, response *SlackResponse, deliveryErr error) {
+	if sink == nil {
+		sink = NewLogDeadLetterSink(logger)
+	}
+
+	err := sink.Record(ctx, DeadLetterEntry{
+		Time:      time.Now(),
+		Target:    request.ResponseURL,
+		Response:  response,
+		Error:     deliveryErr.Error(),
+		Command:   request.Command,
+		Text:      request.Text,
+		UserID:    request.UserID,
+		ChannelID: request.ChannelID,
+		TeamID:    request.TeamID,
+	})
+	if err != nil {
+		logger.Error("could not record undelivered response", zap.Error(err))
+	}
+}
+
+// ReadDeadLetterFile reads the entries recorded by a FileDeadLetterSink,
+// oldest first
+func ReadDeadLetterFile(path string) ([]DeadLetterEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open dead letter file: %w", err)
+	}
+	defer file.Close()
+
+	entries := []DeadLetterEntry{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxDecompressedBodySize)
+	for line := 1; scanner.Scan(); line++ {
+		var entry DeadLetterEntry
+		err := json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dead letter on line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
+
+// Replayer redelivers dead-lettered responses, either to their original
+// target or, since response_urls expire, to a channel with the Web API
+type Replayer struct {
+	responder Responder
+	client    SlackAPI
+}
+
+// NewReplayer creates a Replayer delivering to targets with responder,
+// HTTPResponder if nil, and posting to channels with client, which may
+// be nil if replaying to channels isn't needed
+func NewReplayer(responder Responder, client SlackAPI) *Replayer {
+	if responder == nil {
+		responder = HTTPResponder{}
+	}
+
+	return &Replayer{responder, client}
+}
+
+// Replay delivers the entry's response to its original target again
+func (r *Replayer) Replay(entry DeadLetterEntry) error {
+	if entry.Response == nil {
+		return errors.New("dead letter has no response to replay")
+	}
+
+	return r.responder.Deliver(context.Background(), entry.Target, entry.Response)
+}
+
+// ReplayToChannel posts the entry's response to channel instead of its
+// original target, or to the channel the command was run in if channel
+// is empty. Responses are posted as regular messages, so ephemeral ones
+// become visible to the whole channel.
+func (r *Replayer) ReplayToChannel(entry DeadLetterEntry, channel string) error {
+	if entry.Response == nil {
+		return errors.New("dead letter has no response to replay")
+	}
+	if r.client == nil {
+		return errors.New("replaying to a channel requires a web api client")
+	}
+	if len(channel) == 0 {
+		channel = entry.ChannelID
+	}
+	if len(channel) == 0 {
+		return errors.New("dead letter has no channel to replay to")
+	}
+
+	_, err := r.client.PostBlocks(channel, entry.Response.Text, entry.Response.Blocks)
+	return err
+}
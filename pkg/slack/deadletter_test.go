@@ -0,0 +1,124 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+type failingResponder struct{}
+
+func (failingResponder) Deliver(ctx context.Context, target string, response *SlackResponse) error {
+	return errors.New("expired_url")
+}
+
+type recordingDeadLetterSink struct {
+	entries chan DeadLetterEntry
+}
+
+func (s recordingDeadLetterSink) Record(ctx context.Context, entry DeadLetterEntry) error {
+	s.entries <- entry
+	return nil
+}
+
+func TestUndeliveredResponsesAreDeadLettered(t *testing.T) {
+	sink := recordingDeadLetterSink{make(chan DeadLetterEntry, 1)}
+	var arguments []string
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}}, WithResponder(failingResponder{}), WithDeadLetterSink(sink))
+
+	handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+		"command":      {"/bot"},
+		"text":         {"echo hi"},
+		"user_id":      {"U123"},
+		"channel_id":   {"C123"},
+		"response_url": {"https://hooks.slack.com/commands/T1/1/abc"},
+	}))
+
+	entry := <-sink.entries
+	if entry.Response == nil || entry.Response.Text != "echo" {
+		t.Errorf("expected the undelivered response to be recorded, got %+v", entry.Response)
+	}
+	if entry.Target != "https://hooks.slack.com/commands/T1/1/abc" || entry.Error != "expired_url" {
+		t.Errorf("unexpected target %q or error %q", entry.Target, entry.Error)
+	}
+	if entry.Command != "/bot" || entry.Text != "echo hi" || entry.UserID != "U123" || entry.ChannelID != "C123" {
+		t.Errorf("expected the command's context to be recorded, got %+v", entry)
+	}
+}
+
+func TestFileDeadLetterSinkAppendsEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deadletters.jsonl")
+	sink := NewFileDeadLetterSink(path)
+	for _, text := range []string{"first", "second"} {
+		err := sink.Record(context.Background(), DeadLetterEntry{Target: "https://hooks.slack.com/x", Response: &SlackResponse{Text: text}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	entries, err := ReadDeadLetterFile(path)
+	if err != nil {
+		t.Fatalf("could not read dead letters: %v", err)
+	}
+	texts := []string{}
+	for _, entry := range entries {
+		texts = append(texts, entry.Response.Text)
+	}
+	if len(texts) != 2 || texts[0] != "first" || texts[1] != "second" {
+		t.Errorf("expected both entries in order, got %v", texts)
+	}
+}
+
+func TestReplayDeliversToOriginalTarget(t *testing.T) {
+	responder := fakeResponder{make(chan deliveredResponse, 1)}
+	replayer := NewReplayer(responder, nil)
+
+	err := replayer.Replay(DeadLetterEntry{Target: "https://hooks.slack.com/x", Response: &SlackResponse{Text: "echo"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	delivered := <-responder.deliveries
+	if delivered.target != "https://hooks.slack.com/x" || delivered.response.Text != "echo" {
+		t.Errorf("unexpected delivery %+v", delivered)
+	}
+}
+
+func TestReplayToChannel(t *testing.T) {
+	requests := make(chan map[string]interface{}, 2)
+	server := newAPIServer(t, `{"ok":true,"channel":"C999","ts":"1.2"}`, requests)
+	client := NewClient("xoxb-test")
+	client.apiURL = server.URL + "/"
+	replayer := NewReplayer(failingResponder{}, client)
+	entry := DeadLetterEntry{
+		Target:    "https://hooks.slack.com/expired",
+		ChannelID: "C123",
+		Response:  &SlackResponse{Text: "deployed", Blocks: []Block{NewSectionBlock(NewMarkdownText("*deployed*"))}},
+	}
+
+	// Redirect to another channel
+	err := replayer.ReplayToChannel(entry, "C999")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	posted := <-requests
+	if posted["method"] != "/chat.postMessage" || posted["channel"] != "C999" || posted["text"] != "deployed" {
+		t.Errorf("unexpected post %v", posted)
+	}
+	if blocks, ok := posted["blocks"].([]interface{}); !ok || len(blocks) != 1 {
+		t.Errorf("expected the response's blocks to be posted, got %v", posted["blocks"])
+	}
+
+	// Default to the channel the command was run in
+	err = replayer.ReplayToChannel(entry, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if posted := <-requests; posted["channel"] != "C123" {
+		t.Errorf("expected the command's channel, got %v", posted["channel"])
+	}
+}
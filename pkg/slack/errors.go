@@ -0,0 +1,114 @@
+package slack
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// HandlerError can be returned by handlers to control how the error is
+// shown to users. By default errors are only shown to the requester, but
+// setting ResponseType to `in_channel` makes the failure visible to the
+// whole channel. In-channel errors with an ExpireAfter are deleted once
+// it has passed, provided the bot has a Web API client.
+type HandlerError struct {
+	ResponseType string
+	ExpireAfter  time.Duration
+	Err          error
+}
+
+func (e *HandlerError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *HandlerError) Unwrap() error {
+	return e.Err
+}
+
+// NewInChannelError wraps err so that it is shown to the whole channel
+func NewInChannelError(err error) error {
+	return &HandlerError{
+		ResponseType: "in_channel",
+		Err:          err,
+	}
+}
+
+// NewExpiringInChannelError wraps err so that it is shown to the whole
+// channel and deleted after the given delay. Slack can't delete messages
+// posted to a response_url, so the error is posted with the Web API
+// client set with WithWebAPIClient, and it doesn't expire without one.
+func NewExpiringInChannelError(err error, expireAfter time.Duration) error {
+	return &HandlerError{
+		ResponseType: "in_channel",
+		ExpireAfter:  expireAfter,
+		Err:          err,
+	}
+}
+
+// FieldError is a problem with a single field of a command, such as a
+// flag or an argument
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	if len(e.Field) == 0 {
+		return e.Message
+	}
+
+	return e.Field + " " + e.Message
+}
+
+// ValidationError gathers every problem with a command's arguments, so
+// that users can fix them all at once. It is shown as a bulleted list,
+// one problem per line.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+// Add records a problem with field, such as Add("--env", "is required")
+func (e *ValidationError) Add(field string, message string) {
+	e.Errors = append(e.Errors, FieldError{field, message})
+}
+
+// Err returns the validation error if any problem was added, or nil, so
+// that handlers can return it once every field has been checked
+func (e *ValidationError) Err() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	lines := []string{"That command has some problems:"}
+	for _, fieldError := range e.Errors {
+		lines = append(lines, "• "+fieldError.Error())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// ResponseStatusError is returned by Respond and Client when Slack replies
+// with a non-2xx status
+type ResponseStatusError struct {
+	StatusCode int
+	Reason     string
+}
+
+func (e *ResponseStatusError) Error() string {
+	return fmt.Sprintf("slack returned status %d: %s", e.StatusCode, e.Reason)
+}
+
+// APIError is returned by Client when Slack responds to a Web API call
+// with an error code, such as `channel_not_found`
+type APIError struct {
+	Method string
+	Code   string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s failed: %s", e.Method, e.Code)
+}
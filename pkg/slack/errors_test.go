@@ -0,0 +1,129 @@
+package slack
+
+import (
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type failingHandler struct {
+	recordingHandler
+	err error
+}
+
+func (h failingHandler) Handle(arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	return nil, h.err
+}
+
+func TestErrorResponseVisibility(t *testing.T) {
+	tests := []struct {
+		err                  error
+		expectedResponseType string
+	}{
+		{errors.New("deploy failed"), "ephemeral"},
+		{NewInChannelError(errors.New("deploy failed")), "in_channel"},
+		{fmt.Errorf("wrapped: %w", NewInChannelError(errors.New("deploy failed"))), "in_channel"},
+	}
+
+	for _, test := range tests {
+		server, responses := newResponseServer(t)
+		handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{failingHandler{recordingHandler{name: "deploy"}, test.err}})
+		r := newSignedRequest("abc", url.Values{
+			"text":         {"deploy"},
+			"response_url": {server.URL},
+		})
+		handler(httptest.NewRecorder(), r)
+
+		response := receiveResponse(t, responses)
+		if response.ResponseType != test.expectedResponseType {
+			t.Errorf("error %q was sent as %s, expected %s", test.err, response.ResponseType, test.expectedResponseType)
+		}
+		if response.Text != test.err.Error() {
+			t.Errorf("unexpected error text %q", response.Text)
+		}
+	}
+}
+
+func TestExpiringInChannelErrorsAreDeleted(t *testing.T) {
+	requests := make(chan map[string]interface{}, 2)
+	api := newAPIServer(t, `{"ok":true,"channel":"C123","ts":"1700000000.000100"}`, requests)
+	client := NewClient("xoxb-test")
+	client.apiURL = api.URL + "/"
+	server, responses := newResponseServer(t)
+	err := NewExpiringInChannelError(errors.New("deploy failed"), 10*time.Millisecond)
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{failingHandler{recordingHandler{name: "deploy"}, err}}, WithWebAPIClient(client))
+
+	handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+		"text":         {"deploy"},
+		"channel_id":   {"C123"},
+		"response_url": {server.URL},
+	}))
+
+	// The error is posted to the channel rather than the response_url
+	posted := <-requests
+	if posted["method"] != "/chat.postMessage" || posted["channel"] != "C123" || posted["text"] != "deploy failed" {
+		t.Errorf("unexpected post %v", posted)
+	}
+	select {
+	case response := <-responses:
+		t.Errorf("expected nothing to be posted to the response_url, got %q", response.Text)
+	default:
+	}
+
+	// Then deleted once it expires
+	select {
+	case deleted := <-requests:
+		if deleted["method"] != "/chat.delete" || deleted["channel"] != "C123" || deleted["ts"] != "1700000000.000100" {
+			t.Errorf("unexpected delete %v", deleted)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the expired error was not deleted")
+	}
+}
+
+func TestExpiringErrorsFallBackWithoutClient(t *testing.T) {
+	server, responses := newResponseServer(t)
+	err := NewExpiringInChannelError(errors.New("deploy failed"), time.Minute)
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{failingHandler{recordingHandler{name: "deploy"}, err}})
+
+	handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+		"text":         {"deploy"},
+		"response_url": {server.URL},
+	}))
+
+	response := receiveResponse(t, responses)
+	if response.ResponseType != "in_channel" || response.Text != "deploy failed" {
+		t.Errorf("expected the error in the channel through the response_url, got %+v", response)
+	}
+}
+
+func TestValidationErrorsListEveryProblem(t *testing.T) {
+	validation := &ValidationError{}
+	validation.Add("--env", "is required")
+	validation.Add("count", "must be positive")
+	server, responses := newResponseServer(t)
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{failingHandler{recordingHandler{name: "deploy"}, validation.Err()}})
+
+	handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+		"text":         {"deploy --count -1"},
+		"response_url": {server.URL},
+	}))
+
+	response := receiveResponse(t, responses)
+	expected := "That command has some problems:\n• --env is required\n• count must be positive"
+	if response.ResponseType != "ephemeral" || response.Text != expected {
+		t.Errorf("expected an ephemeral list of both problems, got %+v", response)
+	}
+}
+
+func TestValidationErrorWithoutProblemsIsNil(t *testing.T) {
+	validation := &ValidationError{}
+	if err := validation.Err(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
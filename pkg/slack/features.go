@@ -0,0 +1,38 @@
+package slack
+
+// SlackSlashCommandFeatureFlaggedHandler may be implemented by handlers
+// gated behind a feature flag. They are only registered while the
+// FeatureFlags given with WithFeatureFlags enable their flag, and never
+// without any.
+type SlackSlashCommandFeatureFlaggedHandler interface {
+	SlackSlashCommandHandler
+	FeatureFlag() string
+}
+
+// FeatureFlags tells whether a feature is enabled
+type FeatureFlags interface {
+	Enabled(flag string) bool
+}
+
+// StaticFeatureFlags enables the flags set to true, such as the
+// `features` of the bot's config
+type StaticFeatureFlags map[string]bool
+
+func (f StaticFeatureFlags) Enabled(flag string) bool {
+	return f[flag]
+}
+
+// EnabledHandlers leaves out the handlers whose feature flag is off, as
+// the bot does when registering them
+func EnabledHandlers(handlers []SlackSlashCommandHandler, flags FeatureFlags) []SlackSlashCommandHandler {
+	enabled := []SlackSlashCommandHandler{}
+	for _, handler := range handlers {
+		flaggedHandler, ok := unwrapNamespace(handler).(SlackSlashCommandFeatureFlaggedHandler)
+		if ok && len(flaggedHandler.FeatureFlag()) > 0 && (flags == nil || !flags.Enabled(flaggedHandler.FeatureFlag())) {
+			continue
+		}
+		enabled = append(enabled, handler)
+	}
+
+	return enabled
+}
@@ -0,0 +1,46 @@
+package slack
+
+import "testing"
+
+type flaggedHandler struct {
+	recordingHandler
+	flag string
+}
+
+func (h flaggedHandler) FeatureFlag() string {
+	return h.flag
+}
+
+func TestFeatureFlaggedHandlersFollowTheirFlag(t *testing.T) {
+	flags := StaticFeatureFlags{"beta": false}
+	var arguments []string
+	handlers := []SlackSlashCommandHandler{
+		recordingHandler{"echo", &arguments},
+		flaggedHandler{recordingHandler{"preview", &arguments}, "beta"},
+	}
+	bot := NewSlackBot(0, NewStaticSecretSource("abc", ""), handlers, WithFeatureFlags(flags))
+	registered := func() map[string]bool {
+		names := map[string]bool{}
+		for _, handler := range *bot.handlers.Load() {
+			names[handler.CommandName()] = true
+		}
+		return names
+	}
+
+	if names := registered(); names["preview"] || !names["echo"] {
+		t.Errorf("expected preview to be left out while beta is off, got %v", names)
+	}
+
+	// Replacing the handlers, as config reloads do, re-evaluates the flags
+	flags["beta"] = true
+	bot.SetHandlers(handlers)
+	if names := registered(); !names["preview"] {
+		t.Errorf("expected preview to be registered once beta is on, got %v", names)
+	}
+
+	flags["beta"] = false
+	bot.SetHandlers(handlers)
+	if names := registered(); names["preview"] {
+		t.Errorf("expected preview to be removed once beta is off again, got %v", names)
+	}
+}
@@ -0,0 +1,49 @@
+package slack
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseFlags splits arguments into `--name` or `--name=value` flags and
+// positional arguments. A bare `--` ends flag parsing so that the
+// remaining arguments are positional even if they start with dashes.
+// Flags whose name isn't in allowed result in an error.
+func ParseFlags(arguments []string, allowed ...string) (map[string]string, []string, error) {
+	return parseFlags(arguments, func(name string) bool {
+		return isAllowedFlag(name, allowed)
+	})
+}
+
+func parseFlags(arguments []string, isAllowed func(name string) bool) (map[string]string, []string, error) {
+	flags := map[string]string{}
+	positional := []string{}
+	for i, argument := range arguments {
+		if argument == "--" {
+			positional = append(positional, arguments[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(argument, "--") {
+			positional = append(positional, argument)
+			continue
+		}
+
+		name, value, _ := strings.Cut(strings.TrimPrefix(argument, "--"), "=")
+		if !isAllowed(name) {
+			return nil, nil, fmt.Errorf("unknown flag --%s", name)
+		}
+		flags[name] = value
+	}
+
+	return flags, positional, nil
+}
+
+func isAllowedFlag(name string, allowed []string) bool {
+	for _, allowedName := range allowed {
+		if name == allowedName {
+			return true
+		}
+	}
+
+	return false
+}
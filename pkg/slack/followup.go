@@ -0,0 +1,68 @@
+package slack
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Slack accepts at most this many posts to a response_url
+const maxResponseURLPosts = 5
+
+// followups collects the messages a handler asks to be delivered after
+// its response, in order
+type followups struct {
+	lock      sync.Mutex
+	responses []*SlackResponse
+}
+
+type followupsContextKey struct{}
+
+func withFollowups(ctx context.Context) (context.Context, *followups) {
+	queued := &followups{}
+	return context.WithValue(ctx, followupsContextKey{}, queued), queued
+}
+
+// queueFollowup asks for response to be delivered after the response of
+// the command being handled, decorated and dead-lettered like it. It
+// reports false when the handler wasn't run by the bot, in which case
+// nothing will deliver it.
+func queueFollowup(ctx context.Context, response *SlackResponse) bool {
+	queued, ok := ctx.Value(followupsContextKey{}).(*followups)
+	if !ok {
+		return false
+	}
+	queued.lock.Lock()
+	defer queued.lock.Unlock()
+	queued.responses = append(queued.responses, response)
+
+	return true
+}
+
+func (f *followups) take() []*SlackResponse {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	responses := f.responses
+	f.responses = nil
+
+	return responses
+}
+
+// deliverFollowups delivers the queued messages in the background, so
+// that they don't hold up the acknowledgement of the command, stopping
+// at the first that can't be delivered
+func deliverFollowups(ctx context.Context, logger *zap.Logger, opts slackBotOptions, request SlackSlashCommandBody, queued *followups) {
+	responses := queued.take()
+	if len(responses) == 0 {
+		return
+	}
+
+	go func() {
+		for _, response := range responses {
+			if !deliverResponse(ctx, logger, opts, request, decorate(response, opts.responsePrefix, opts.responseSuffix)) {
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,48 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+)
+
+type formContextKey struct{}
+
+// FormFromContext returns every field of the handled request, keyed by
+// Slack's field names such as `is_enterprise_install`, so that handlers
+// can read fields SlackSlashCommandBody doesn't have. The map is a copy
+// that handlers are free to modify.
+func FormFromContext(ctx context.Context) (map[string]string, bool) {
+	form, ok := ctx.Value(formContextKey{}).(map[string]string)
+	if !ok {
+		return nil, false
+	}
+
+	copied := make(map[string]string, len(form))
+	for key, value := range form {
+		copied[key] = value
+	}
+
+	return copied, true
+}
+
+func withForm(ctx context.Context, form map[string]string) context.Context {
+	return context.WithValue(ctx, formContextKey{}, form)
+}
+
+// stringFields converts a socket mode payload into the form fields the
+// same command would have over HTTP
+func stringFields(payload map[string]interface{}) map[string]string {
+	fields := make(map[string]string, len(payload))
+	for key, value := range payload {
+		if value == nil {
+			continue
+		}
+		if text, ok := value.(string); ok {
+			fields[key] = text
+			continue
+		}
+		fields[key] = fmt.Sprint(value)
+	}
+
+	return fields
+}
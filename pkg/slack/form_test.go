@@ -0,0 +1,41 @@
+package slack
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// formFieldHandler responds with a form field SlackSlashCommandBody
+// doesn't have
+type formFieldHandler struct {
+	recordingHandler
+	field string
+}
+
+func (h formFieldHandler) HandleContext(ctx context.Context, arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	form, ok := FormFromContext(ctx)
+	if !ok {
+		return &SlackResponse{Text: "no form"}, nil
+	}
+
+	return &SlackResponse{Text: form[h.field]}, nil
+}
+
+func TestHandlersCanReadArbitraryFormFields(t *testing.T) {
+	server, responses := newResponseServer(t)
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{formFieldHandler{recordingHandler{name: "enterprise"}, "is_enterprise_install"}})
+
+	handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+		"text":                  {"enterprise"},
+		"is_enterprise_install": {"true"},
+		"response_url":          {server.URL},
+	}))
+
+	if response := receiveResponse(t, responses); response.Text != "true" {
+		t.Errorf("expected the handler to read is_enterprise_install, got %q", response.Text)
+	}
+}
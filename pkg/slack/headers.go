@@ -0,0 +1,44 @@
+package slack
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Canonical names of the headers Slack signs its requests with
+const (
+	SignatureHeaderName = "X-Slack-Signature"
+	TimestampHeaderName = "X-Slack-Request-Timestamp"
+)
+
+// headerValue reads a header by its canonical name, falling back to a
+// case-insensitive search for headers stored without canonicalization,
+// which some proxies and middleware do by writing to the map directly
+func headerValue(header http.Header, name string) string {
+	if value := header.Get(name); len(value) > 0 {
+		return value
+	}
+	for key, values := range header {
+		if strings.EqualFold(key, name) && len(values) > 0 {
+			return values[0]
+		}
+	}
+
+	return ""
+}
+
+// isUnixTimestamp reports whether value is made up only of digits, as
+// Slack's timestamps are, rejecting signs and spaces that
+// strconv.ParseInt would otherwise accept or trip on
+func isUnixTimestamp(value string) bool {
+	if len(value) == 0 {
+		return false
+	}
+	for _, c := range value {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,116 @@
+package slack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestSignatureHeadersAreReadRegardlessOfCase(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}})
+	form := url.Values{
+		"text":         {"echo"},
+		"response_url": {server.URL},
+	}
+
+	casings := []struct {
+		signature string
+		timestamp string
+	}{
+		{"X-SLACK-SIGNATURE", "X-SLACK-REQUEST-TIMESTAMP"},
+		{"x-slack-signature", "x-slack-request-timestamp"},
+		{"x-Slack-signature", "X-slack-Request-timestamp"},
+	}
+	for _, casing := range casings {
+		// Store the headers without canonicalizing their names, as
+		// middleware writing to the map directly would
+		signed := newSignedRequest("abc", form)
+		r := httptest.NewRequest("POST", "/", signed.Body)
+		r.Header.Set("content-type", "application/x-www-form-urlencoded")
+		r.Header[casing.signature] = []string{signed.Header.Get(SignatureHeaderName)}
+		r.Header[casing.timestamp] = []string{signed.Header.Get(TimestampHeaderName)}
+		handler(httptest.NewRecorder(), r)
+
+		select {
+		case response := <-responses:
+			if response.Text != "echo" {
+				t.Errorf("%v: unexpected response %q", casing, response.Text)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("headers named %v were not read", casing)
+		}
+	}
+}
+
+func TestNonNumericTimestampIsRejected(t *testing.T) {
+	var arguments []string
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}})
+
+	for _, timestamp := range []string{"+" + strconv.FormatInt(time.Now().Unix(), 10), "now", " 1700000000"} {
+		// Sign the request with the timestamp as is, so only its format
+		// can cause the rejection
+		body := "text=echo"
+		mac := hmac.New(sha256.New, []byte("abc"))
+		mac.Write([]byte(fmt.Sprintf("v0:%s:%s", timestamp, body)))
+		r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		r.Header.Set("content-type", "application/x-www-form-urlencoded")
+		r.Header.Set(TimestampHeaderName, timestamp)
+		r.Header.Set(SignatureHeaderName, "v0="+hex.EncodeToString(mac.Sum(nil)))
+		w := httptest.NewRecorder()
+		handler(w, r)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for timestamp %q, got %d", timestamp, w.Code)
+		}
+	}
+	if arguments != nil {
+		t.Errorf("handler ran for a non-numeric timestamp")
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte("text=echo")
+	signed := newSignedRequestWithBody("abc", string(body))
+	timestamp := signed.Header.Get(TimestampHeaderName)
+	signature := signed.Header.Get(SignatureHeaderName)
+
+	if !VerifySignature("abc", timestamp, body, signature) {
+		t.Errorf("expected a valid signature to verify")
+	}
+	for _, invalid := range []string{"", "v", "v0=", "v1" + signature[2:], signature[:len(signature)-1], signature + "0"} {
+		if VerifySignature("abc", timestamp, body, invalid) {
+			t.Errorf("expected signature %q not to verify", invalid)
+		}
+	}
+	if VerifySignature("other", timestamp, body, signature) {
+		t.Errorf("expected a signature from another key not to verify")
+	}
+}
+
+func TestMalformedSignaturesAreRejected(t *testing.T) {
+	var arguments []string
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}})
+
+	// Signatures shorter than a full v0 signature must be rejected
+	// without slicing past their end
+	for _, signature := range []string{"v", "v0", "v0=", "=", "v0=zz"} {
+		r := newSignedRequestWithBody("abc", "text=echo")
+		r.Header.Set(SignatureHeaderName, signature)
+		handler(httptest.NewRecorder(), r)
+	}
+	if arguments != nil {
+		t.Errorf("handler ran for a malformed signature")
+	}
+}
@@ -0,0 +1,74 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Paths the bot serves its health on, which can't be used as mounts
+const (
+	healthPath    = "/healthz"
+	readinessPath = "/readyz"
+)
+
+type healthStatus struct {
+	Status   string            `json:"status"`
+	Degraded map[string]string `json:"degraded,omitempty"`
+}
+
+// healthHandler reports the bot as `ok` or `degraded` along with the
+// reasons features are degraded. When requireReady is set, it replies 503
+// until the readiness gate is ready. Degraded bots still serve requests,
+// so they are reported as ready.
+func healthHandler(readiness *ReadinessGate, requireReady bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := healthStatus{Status: "ok"}
+		code := http.StatusOK
+		if readiness != nil {
+			status.Degraded = readiness.Degraded()
+			if len(status.Degraded) > 0 {
+				status.Status = "degraded"
+			}
+			if requireReady && !readiness.Ready() {
+				status.Status = "not_ready"
+				code = http.StatusServiceUnavailable
+			}
+		}
+
+		w.Header().Set("content-type", "application/json; charset=utf-8")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(status)
+	}
+}
+
+// WatchWebAPI checks that client can reach the Web API with its token,
+// immediately and then every interval until ctx is done, marking the
+// `web_api` feature degraded in readiness while it can't. The bot keeps
+// serving commands that don't need the Web API in the meantime.
+func WatchWebAPI(ctx context.Context, logger *zap.Logger, client SlackAPI, readiness *ReadinessGate, interval time.Duration) {
+	for {
+		err := client.AuthTest()
+		_, wasDegraded := readiness.Degraded()["web_api"]
+		if err != nil {
+			if !wasDegraded {
+				logger.Warn("web api is unavailable, features relying on it are degraded", zap.Error(err))
+			}
+			readiness.MarkDegraded("web_api", err.Error())
+		} else {
+			if wasDegraded {
+				logger.Info("web api is available again")
+			}
+			readiness.MarkRecovered("web_api")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
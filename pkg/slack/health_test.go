@@ -0,0 +1,77 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+)
+
+func TestWebAPIUnavailableAtBootDegradesBot(t *testing.T) {
+	server, responses := newResponseServer(t)
+	api := httptest.NewServer(http.NotFoundHandler())
+	api.Close()
+	client := NewClient("xoxb-test")
+	client.apiURL = api.URL + "/"
+	readiness := NewReadinessGate()
+	var arguments []string
+	bot := NewSlackBot(0, NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}}, WithReadinessGate(readiness))
+	mux := bot.buildMux(zap.NewNop())
+	health := func(path string) (int, healthStatus) {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		var status healthStatus
+		json.NewDecoder(w.Body).Decode(&status)
+		return w.Code, status
+	}
+
+	if code, status := health("/readyz"); code != http.StatusServiceUnavailable || status.Status != "not_ready" {
+		t.Errorf("expected not to be ready before boot, got %d %+v", code, status)
+	}
+
+	// Check the Web API once, as at boot, while it can't be reached
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	WatchWebAPI(ctx, zap.NewNop(), client, readiness, time.Minute)
+	readiness.MarkReady()
+
+	for _, path := range []string{"/healthz", "/readyz"} {
+		code, status := health(path)
+		if code != http.StatusOK || status.Status != "degraded" || len(status.Degraded["web_api"]) == 0 {
+			t.Errorf("expected %s to report the web api as degraded, got %d %+v", path, code, status)
+		}
+	}
+	if degraded := testutil.ToFloat64(degradedFeatures.WithLabelValues("web_api")); degraded != 1 {
+		t.Errorf("expected the degraded metric to be set, got %v", degraded)
+	}
+
+	// Commands that don't need the Web API are still served
+	r := newSignedRequest("abc", url.Values{
+		"text":         {"echo hi"},
+		"response_url": {server.URL},
+	})
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+	if response := receiveResponse(t, responses); response.Text != "echo" {
+		t.Errorf("expected echo to be served while degraded, got %q", response.Text)
+	}
+
+	readiness.MarkRecovered("web_api")
+	if code, status := health("/healthz"); code != http.StatusOK || status.Status != "ok" {
+		t.Errorf("expected to recover, got %d %+v", code, status)
+	}
+}
+
+func TestHealthPathsCantBeMounted(t *testing.T) {
+	bot := NewSlackBot(0, NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{})
+	for _, path := range []string{"/healthz", "/readyz"} {
+		if bot.Mount(path, NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{}) == nil {
+			t.Errorf("expected mounting %s to fail", path)
+		}
+	}
+}
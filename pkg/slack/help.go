@@ -1,43 +1,250 @@
 package slack
 
 import (
+	"context"
 	"fmt"
+	"strconv"
+	"strings"
 )
 
+// One block is reserved for the header and one for the page footer
+const helpCommandsPerPage = maxBlocksPerMessage - 2
+
+// Slack recommends keeping the text of a message under this length
+const defaultHelpMessageLimit = 4000
+
 type HelpHandler struct {
-	handlers *[]SlackSlashCommandHandler
+	name         string
+	handlers     *[]SlackSlashCommandHandler
+	showHidden   bool
+	admins       map[string]bool
+	messageLimit int
+	// namespaceSeparator joins namespaced commands to their namespace
+	namespaceSeparator string
+}
+
+func NewHelpHandler(name string, handlers *[]SlackSlashCommandHandler) SlackSlashCommandHandler {
+	return HelpHandler{
+		name:               name,
+		handlers:           handlers,
+		namespaceSeparator: defaultNamespaceSeparator,
+	}
+}
+
+// NewHelpHandlerWithVisibility builds a help handler that lists hidden
+// commands when showHidden is set, or when the user asking for help is
+// one of the admins
+func NewHelpHandlerWithVisibility(name string, handlers *[]SlackSlashCommandHandler, showHidden bool, admins []string) SlackSlashCommandHandler {
+	return newHelpHandler(name, handlers, showHidden, admins)
 }
 
-func NewHelpHandler(handlers *[]SlackSlashCommandHandler) SlackSlashCommandHandler {
+func newHelpHandler(name string, handlers *[]SlackSlashCommandHandler, showHidden bool, admins []string) HelpHandler {
+	adminSet := make(map[string]bool, len(admins))
+	for _, admin := range admins {
+		adminSet[admin] = true
+	}
+
 	return HelpHandler{
-		handlers,
+		name:               name,
+		handlers:           handlers,
+		showHidden:         showHidden,
+		admins:             adminSet,
+		namespaceSeparator: defaultNamespaceSeparator,
+	}
+}
+
+// SlackSlashCommandHelpTextHandler may be implemented by handlers that want
+// to show more detailed help when it is requested specifically for them,
+// with `help <command>`, while keeping their description short in the
+// list of all commands
+type SlackSlashCommandHelpTextHandler interface {
+	SlackSlashCommandHandler
+	HelpText() string
+}
+
+// SlackSlashCommandHiddenHandler may be implemented by handlers that
+// should be left out of the list of all commands, such as dangerous
+// commands in production. Hidden commands can still be run, and their
+// help is still shown with `help <command>`.
+type SlackSlashCommandHiddenHandler interface {
+	SlackSlashCommandHandler
+	Hidden() bool
+}
+
+// isHidden reports whether the handler asked to be left out of help
+func isHidden(handler SlackSlashCommandHandler) bool {
+	hiddenHandler, ok := unwrapNamespace(handler).(SlackSlashCommandHiddenHandler)
+	return ok && hiddenHandler.Hidden()
+}
+
+// visibleHandlers returns the handlers to list for the user asking for
+// help, grouped by namespace
+func (a HelpHandler) visibleHandlers(userID string) []SlackSlashCommandHandler {
+	if a.showHidden || a.admins[userID] {
+		return groupByNamespace(*a.handlers)
+	}
+
+	visible := []SlackSlashCommandHandler{}
+	for _, handler := range *a.handlers {
+		if !isHidden(handler) {
+			visible = append(visible, handler)
+		}
 	}
+
+	return groupByNamespace(visible)
 }
 
 func (a HelpHandler) Handle(arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	return a.HandleContext(context.Background(), arguments, request)
+}
+
+func (a HelpHandler) HandleContext(ctx context.Context, arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	// Show detailed help when it is requested for a specific command
+	if len(arguments) > 0 {
+		for _, handler := range *a.handlers {
+			if matchesCommand(qualifiedName(handler, a.namespaceSeparator), arguments[0], caseSensitiveCommandsFromContext(ctx)) {
+				return a.commandHelp(handler), nil
+			}
+		}
+	}
+
+	// Otherwise, split the commands into pages and work out which page
+	// was requested
+	pages := a.pages(a.visibleHandlers(request.UserID))
+	if len(arguments) > 0 {
+		page, err := strconv.Atoi(arguments[0])
+		if err != nil || page < 1 {
+			return nil, fmt.Errorf("%s is neither a command nor a valid help page\n%s", arguments[0], UsageString(a))
+		}
+		if page > len(pages) {
+			return nil, fmt.Errorf("help page %d does not exist, there are %d pages", page, len(pages))
+		}
+		return a.page(page, pages), nil
+	}
+
+	// Return the first page and have the bot deliver the following ones
+	// after it, as many as the response_url accepts, the footer telling
+	// users how to see the others. Only the first page is shown when the
+	// handler isn't run by the bot.
+	for page := 2; page <= min(len(pages), maxResponseURLPosts); page++ {
+		if !queueFollowup(ctx, a.page(page, pages)) {
+			break
+		}
+	}
+
+	return a.page(1, pages), nil
+}
+
+// pages splits the handlers into pages that each fit in a message,
+// within both Slack's block limit and the message length limit
+func (a HelpHandler) pages(handlers []SlackSlashCommandHandler) [][]SlackSlashCommandHandler {
+	limit := a.messageLimit
+	if limit <= 0 {
+		limit = defaultHelpMessageLimit
+	}
+
+	pages := [][]SlackSlashCommandHandler{{}}
+	length := 0
+	blocks := 0
+	for _, handler := range handlers {
+		entryLength := len(a.helpEntry(handler)) + 1
+		current := pages[len(pages)-1]
+		if len(current) > 0 && (blocks+helpEntryBlocks(current, handler) > helpCommandsPerPage || length+entryLength > limit) {
+			pages = append(pages, []SlackSlashCommandHandler{})
+			length = 0
+			blocks = 0
+		}
+		blocks += helpEntryBlocks(pages[len(pages)-1], handler)
+		pages[len(pages)-1] = append(pages[len(pages)-1], handler)
+		length += entryLength
+	}
+
+	return pages
+}
+
+// helpEntryBlocks counts the blocks listing the handler takes after the
+// handlers already on its page, including a heading for its namespace
+// when it is the first of the namespace on the page
+func helpEntryBlocks(page []SlackSlashCommandHandler, handler SlackSlashCommandHandler) int {
+	namespace := namespaceOf(handler)
+	if len(namespace) > 0 && (len(page) == 0 || namespaceOf(page[len(page)-1]) != namespace) {
+		return 2
+	}
+
+	return 1
+}
+
+// helpEntry describes a handler in the plain text list of commands
+func (a HelpHandler) helpEntry(handler SlackSlashCommandHandler) string {
+	return fmt.Sprintf("%s %s\n%s\n", qualifiedName(handler, a.namespaceSeparator), handler.CommandArguments(), handler.CommandDescription())
+}
+
+// page builds the message listing the commands of a page, numbered from
+// one
+func (a HelpHandler) page(page int, pages [][]SlackSlashCommandHandler) *SlackResponse {
+	pageHandlers := pages[page-1]
+
+	// Build the blocks and the plain text fallback for clients that
+	// don't render blocks
 	helpText := ""
-	for i, handler := range *a.handlers {
-		helpText += fmt.Sprintf("%s %s\n%s\n", handler.CommandName(), handler.CommandArguments(), handler.CommandDescription())
+	blocks := []Block{NewHeaderBlock("Available commands")}
+	for i, handler := range pageHandlers {
+		// Head each namespace, including one carried over from the
+		// previous page
+		if helpEntryBlocks(pageHandlers[:i], handler) > 1 {
+			helpText += namespaceOf(handler) + "\n"
+			blocks = append(blocks, NewHeaderBlock(namespaceOf(handler)))
+		}
+		helpText += a.helpEntry(handler)
+		blocks = append(blocks, NewSectionBlock(NewMarkdownText(fmt.Sprintf("*%s* %s\n%s", qualifiedName(handler, a.namespaceSeparator), handler.CommandArguments(), handler.CommandDescription()))))
 
-		if i < len(*a.handlers)-1 {
+		if i < len(pageHandlers)-1 {
 			helpText += "\n"
 		}
 	}
+	if len(pages) > 1 {
+		blocks = append(blocks, NewContextBlock(NewMarkdownText(fmt.Sprintf("Page %d of %d, use `%s <page>` to see a single page", page, len(pages), a.CommandName()))))
+	}
 
 	return &SlackResponse{
 		ResponseType: "ephemeral",
 		Text:         helpText,
-	}, nil
+		Blocks:       blocks,
+	}
+}
+
+func (a HelpHandler) commandHelp(handler SlackSlashCommandHandler) *SlackResponse {
+	helpText := handler.CommandDescription()
+	if helpTextHandler, ok := unwrapNamespace(handler).(SlackSlashCommandHelpTextHandler); ok && len(helpTextHandler.HelpText()) > 0 {
+		helpText = helpTextHandler.HelpText()
+	}
+	name := qualifiedName(handler, a.namespaceSeparator)
+	usage := strings.TrimSpace(fmt.Sprintf("Usage: %s %s", name, handler.CommandArguments()))
+
+	return &SlackResponse{
+		ResponseType: "ephemeral",
+		Text:         fmt.Sprintf("%s\n%s\n", usage, helpText),
+		Blocks: []Block{
+			NewHeaderBlock(name),
+			NewSectionBlock(NewMarkdownText(fmt.Sprintf("`%s`\n%s", usage, helpText))),
+		},
+	}
 }
 
 func (a HelpHandler) CommandName() string {
-	return "help"
+	return a.name
 }
 
 func (a HelpHandler) CommandArguments() string {
-	return ""
+	return "[command|page]"
 }
 
 func (a HelpHandler) CommandDescription() string {
 	return "Displays a list of the available commands, their arguments, and their description"
 }
+
+// UsageString builds a consistent usage line for a handler, such as
+// `Usage: echo [words...]`, for use in error messages
+func UsageString(h SlackSlashCommandHandler) string {
+	return strings.TrimSpace(fmt.Sprintf("Usage: %s %s", h.CommandName(), h.CommandArguments()))
+}
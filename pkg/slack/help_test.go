@@ -0,0 +1,364 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type describedHandler struct {
+	name        string
+	arguments   string
+	description string
+}
+
+func (h describedHandler) Handle(arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	return nil, nil
+}
+
+func (h describedHandler) CommandName() string {
+	return h.name
+}
+
+func (h describedHandler) CommandArguments() string {
+	return h.arguments
+}
+
+func (h describedHandler) CommandDescription() string {
+	return h.description
+}
+
+func TestHelpRendersBlocks(t *testing.T) {
+	handlers := []SlackSlashCommandHandler{
+		describedHandler{"echo", "[words...]", "Echoes words"},
+		describedHandler{"ping", "", "Replies with pong"},
+	}
+	response, err := NewHelpHandler("help", &handlers).Handle([]string{}, SlackSlashCommandBody{})
+	if err != nil {
+		t.Fatalf("help returned an error: %v", err)
+	}
+
+	if len(response.Blocks) != 3 {
+		t.Fatalf("expected a header and two sections, got %d blocks", len(response.Blocks))
+	}
+	if response.Blocks[0].Type != "header" {
+		t.Errorf("expected the first block to be a header, got %s", response.Blocks[0].Type)
+	}
+	expectedSections := []string{"*echo* [words...]\nEchoes words", "*ping* \nReplies with pong"}
+	for i, expected := range expectedSections {
+		block := response.Blocks[i+1]
+		if block.Type != "section" || block.Text.Type != "mrkdwn" || block.Text.Text != expected {
+			t.Errorf("unexpected section %d: %+v", i, block)
+		}
+	}
+	if response.Text != "echo [words...]\nEchoes words\n\nping \nReplies with pong\n" {
+		t.Errorf("unexpected plain text fallback %q", response.Text)
+	}
+}
+
+func TestHelpPaginatesBlocks(t *testing.T) {
+	handlers := []SlackSlashCommandHandler{}
+	for i := 0; i < helpCommandsPerPage+1; i++ {
+		handlers = append(handlers, describedHandler{fmt.Sprintf("command%d", i), "", ""})
+	}
+	help := NewHelpHandler("help", &handlers)
+
+	firstPage, err := help.Handle([]string{}, SlackSlashCommandBody{})
+	if err != nil {
+		t.Fatalf("help returned an error: %v", err)
+	}
+	if len(firstPage.Blocks) > maxBlocksPerMessage {
+		t.Errorf("first page has %d blocks, more than Slack allows", len(firstPage.Blocks))
+	}
+
+	secondPage, err := help.Handle([]string{"2"}, SlackSlashCommandBody{})
+	if err != nil {
+		t.Fatalf("help returned an error: %v", err)
+	}
+	if len(secondPage.Blocks) != 3 || secondPage.Blocks[1].Text.Text != fmt.Sprintf("*command%d* \n", helpCommandsPerPage) {
+		t.Errorf("unexpected second page: %+v", secondPage.Blocks)
+	}
+
+	_, err = help.Handle([]string{"3"}, SlackSlashCommandBody{})
+	if err == nil {
+		t.Errorf("expected an error for a page that does not exist")
+	}
+}
+
+func TestHelpListsEveryRegisteredHandler(t *testing.T) {
+	handlers := []SlackSlashCommandHandler{
+		describedHandler{"echo", "[words...]", "Echoes words"},
+		describedHandler{"ping", "", "Replies with pong"},
+		describedHandler{"deploy", "<service>", "Deploys a service"},
+	}
+	bot := NewSlackBot(8080, NewStaticSecretSource("abc", ""), handlers)
+
+	var help SlackSlashCommandHandler
+	for _, handler := range *bot.handlers.Load() {
+		if handler.CommandName() == "help" {
+			help = handler
+		}
+	}
+	if help == nil {
+		t.Fatalf("help handler was not registered")
+	}
+	response, err := help.Handle([]string{}, SlackSlashCommandBody{})
+	if err != nil {
+		t.Fatalf("help returned an error: %v", err)
+	}
+
+	for _, handler := range *bot.handlers.Load() {
+		if !strings.Contains(response.Text, handler.CommandName()) || !strings.Contains(response.Text, handler.CommandDescription()) {
+			t.Errorf("help text is missing %s", handler.CommandName())
+		}
+
+		found := false
+		for _, block := range response.Blocks {
+			if block.Type == "section" && strings.Contains(block.Text.Text, "*"+handler.CommandName()+"*") && strings.Contains(block.Text.Text, handler.CommandDescription()) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("help blocks are missing %s", handler.CommandName())
+		}
+	}
+}
+
+func TestRenamedHelpCommand(t *testing.T) {
+	server, responses := newResponseServer(t)
+	handlers := []SlackSlashCommandHandler{describedHandler{"echo", "[words...]", "Echoes words"}}
+	bot := NewSlackBot(8080, NewStaticSecretSource("abc", ""), handlers, WithHelpCommandName("commands"))
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), *bot.handlers.Load(), bot.options...)
+
+	for _, text := range []string{"commands", ""} {
+		r := newSignedRequest("abc", url.Values{
+			"text":         {text},
+			"response_url": {server.URL},
+		})
+		handler(httptest.NewRecorder(), r)
+
+		response := receiveResponse(t, responses)
+		if !strings.Contains(response.Text, "commands [command|page]") || !strings.Contains(response.Text, "echo [words...]") {
+			t.Errorf("text %q did not return the renamed help, got %q", text, response.Text)
+		}
+	}
+}
+
+func TestUsageString(t *testing.T) {
+	tests := []struct {
+		handler  SlackSlashCommandHandler
+		expected string
+	}{
+		{describedHandler{"echo", "[words...]", ""}, "Usage: echo [words...]"},
+		{describedHandler{"ping", "", ""}, "Usage: ping"},
+	}
+
+	for _, test := range tests {
+		if usage := UsageString(test.handler); usage != test.expected {
+			t.Errorf("expected %q, got %q", test.expected, usage)
+		}
+	}
+}
+
+type detailedHandler struct {
+	describedHandler
+}
+
+func (h detailedHandler) HelpText() string {
+	return "Deploys the given service to the given environment, waiting for its rollout to complete"
+}
+
+func TestHelpTextOverrideOnlyForTargetedHelp(t *testing.T) {
+	handlers := []SlackSlashCommandHandler{
+		detailedHandler{describedHandler{"deploy", "<service> <env>", "Deploys a service"}},
+		describedHandler{"ping", "", "Replies with pong"},
+	}
+	help := NewHelpHandler("help", &handlers)
+	detailed := detailedHandler{}.HelpText()
+
+	general, err := help.Handle([]string{}, SlackSlashCommandBody{})
+	if err != nil {
+		t.Fatalf("help returned an error: %v", err)
+	}
+	if strings.Contains(general.Text, detailed) || !strings.Contains(general.Text, "Deploys a service") {
+		t.Errorf("general help should only show the description, got %q", general.Text)
+	}
+
+	targeted, err := help.Handle([]string{"deploy"}, SlackSlashCommandBody{})
+	if err != nil {
+		t.Fatalf("help returned an error: %v", err)
+	}
+	if !strings.Contains(targeted.Text, detailed) || !strings.Contains(targeted.Text, "Usage: deploy <service> <env>") {
+		t.Errorf("targeted help should show the detailed help text, got %q", targeted.Text)
+	}
+
+	fallback, err := help.Handle([]string{"ping"}, SlackSlashCommandBody{})
+	if err != nil {
+		t.Fatalf("help returned an error: %v", err)
+	}
+	if !strings.Contains(fallback.Text, "Replies with pong") {
+		t.Errorf("targeted help should fall back to the description, got %q", fallback.Text)
+	}
+
+	_, err = help.Handle([]string{"nope"}, SlackSlashCommandBody{})
+	if err == nil {
+		t.Errorf("expected an error for an unknown command")
+	}
+}
+
+func TestTargetedHelpFollowsCaseSensitivity(t *testing.T) {
+	handlers := []SlackSlashCommandHandler{describedHandler{"deploy", "<service>", "Deploys a service"}}
+	help := NewHelpHandler("help", &handlers).(HelpHandler)
+
+	response, err := help.HandleContext(context.Background(), []string{"DEPLOY"}, SlackSlashCommandBody{})
+	if err != nil || !strings.Contains(response.Text, "Usage: deploy <service>") {
+		t.Errorf("expected targeted help regardless of case by default, got %+v, %v", response, err)
+	}
+
+	_, err = help.HandleContext(withCaseSensitiveCommands(context.Background(), true), []string{"DEPLOY"}, SlackSlashCommandBody{})
+	if err == nil {
+		t.Errorf("expected no help for a command of another case when commands are case sensitive")
+	}
+}
+
+type hiddenHandler struct {
+	describedHandler
+}
+
+func (h hiddenHandler) Hidden() bool {
+	return true
+}
+
+func TestHelpHidesHiddenCommandsFromNonAdmins(t *testing.T) {
+	handlers := []SlackSlashCommandHandler{
+		describedHandler{"echo", "[words...]", "Echoes words"},
+		hiddenHandler{describedHandler{"drop-database", "", "Drops the database"}},
+	}
+	help := NewHelpHandlerWithVisibility("help", &handlers, false, []string{"UADMIN"})
+
+	// General help omits the hidden command
+	response, err := help.Handle([]string{}, SlackSlashCommandBody{UserID: "UUSER"})
+	if err != nil {
+		t.Fatalf("help returned an error: %v", err)
+	}
+	if strings.Contains(response.Text, "drop-database") {
+		t.Errorf("expected the hidden command to be absent from general help, got %q", response.Text)
+	}
+	if !strings.Contains(response.Text, "echo") {
+		t.Errorf("expected the visible command to be listed, got %q", response.Text)
+	}
+
+	// Admins see it in the list
+	response, err = help.Handle([]string{}, SlackSlashCommandBody{UserID: "UADMIN"})
+	if err != nil {
+		t.Fatalf("help returned an error: %v", err)
+	}
+	if !strings.Contains(response.Text, "drop-database") {
+		t.Errorf("expected the hidden command to be listed for an admin, got %q", response.Text)
+	}
+
+	// Explicit help still describes it
+	response, err = help.Handle([]string{"drop-database"}, SlackSlashCommandBody{UserID: "UUSER"})
+	if err != nil {
+		t.Fatalf("help returned an error: %v", err)
+	}
+	if !strings.Contains(response.Text, "Drops the database") {
+		t.Errorf("expected explicit help for the hidden command, got %q", response.Text)
+	}
+}
+
+func TestHelpShowsHiddenCommandsWhenConfigured(t *testing.T) {
+	handlers := []SlackSlashCommandHandler{
+		hiddenHandler{describedHandler{"drop-database", "", "Drops the database"}},
+	}
+	response, err := NewHelpHandlerWithVisibility("help", &handlers, true, nil).Handle([]string{}, SlackSlashCommandBody{})
+	if err != nil {
+		t.Fatalf("help returned an error: %v", err)
+	}
+	if !strings.Contains(response.Text, "drop-database") {
+		t.Errorf("expected hidden commands to be listed, got %q", response.Text)
+	}
+}
+
+func TestHelpIsSplitAcrossDeliveries(t *testing.T) {
+	server, responses := newResponseServer(t)
+	handlers := []SlackSlashCommandHandler{}
+	for i := 0; i < 50; i++ {
+		handlers = append(handlers, describedHandler{fmt.Sprintf("command%d", i), "<argument>", "Does something useful with its argument"})
+	}
+	bot := NewSlackBot(8080, NewStaticSecretSource("abc", ""), handlers, WithHelpMessageLimit(1000))
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), *bot.handlers.Load(), bot.options...)
+
+	handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+		"text":         {"help"},
+		"response_url": {server.URL},
+	}))
+
+	// Every command is listed once, in order, across several messages
+	listed := []string{}
+	deliveries := 0
+	for len(listed) < len(*bot.handlers.Load()) {
+		response := receiveResponse(t, responses)
+		deliveries++
+		if len(response.Text) > 1000 {
+			t.Errorf("delivery %d is %d long, over the limit", deliveries, len(response.Text))
+		}
+		for _, block := range response.Blocks {
+			if block.Type == "section" {
+				listed = append(listed, strings.SplitN(block.Text.Text, "*", 3)[1])
+			}
+		}
+	}
+	if deliveries < 2 {
+		t.Errorf("expected the help to be split across deliveries, got %d", deliveries)
+	}
+	for i, name := range listed[:50] {
+		if name != fmt.Sprintf("command%d", i) {
+			t.Errorf("expected command%d to be listed in position %d, got %s", i, i, name)
+		}
+	}
+}
+
+func TestHelpPagesAreCappedAndDecorated(t *testing.T) {
+	responder := fakeResponder{make(chan deliveredResponse, 10)}
+	handlers := []SlackSlashCommandHandler{}
+	for i := 0; i < helpCommandsPerPage*7+1; i++ {
+		handlers = append(handlers, describedHandler{fmt.Sprintf("command%d", i), "", ""})
+	}
+	options := []SlackBotOption{WithResponder(responder), WithResponseDecoration("[staging] ", "")}
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), withHelpHandler(handlers, options), options...)
+
+	handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+		"text":         {"help"},
+		"response_url": {"https://hooks.slack.com/commands/1"},
+	}))
+
+	// Only as many pages as the response_url accepts are posted, each
+	// decorated like any other response
+	for page := 1; page <= maxResponseURLPosts; page++ {
+		select {
+		case delivery := <-responder.deliveries:
+			if !strings.HasPrefix(delivery.response.Text, "[staging] ") {
+				t.Errorf("expected page %d to be decorated, got %q", page, delivery.response.Text)
+			}
+			blocks, _ := json.Marshal(delivery.response.Blocks)
+			if !strings.Contains(string(blocks), fmt.Sprintf("Page %d of 8", page)) {
+				t.Errorf("expected page %d, got %s", page, blocks)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("page %d was not delivered", page)
+		}
+	}
+	select {
+	case delivery := <-responder.deliveries:
+		t.Errorf("expected no more than %d pages, got %+v", maxResponseURLPosts, delivery.response)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,139 @@
+package slack
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const historyCommandName = "history"
+
+// How many commands are remembered for each user by default
+const defaultHistorySize = 20
+
+// SlackSlashCommandSensitiveHandler may be implemented by handlers whose
+// arguments shouldn't be kept, such as ones taking secrets. When
+// SensitiveArguments returns true, their arguments are redacted from the
+// CommandHistory.
+type SlackSlashCommandSensitiveHandler interface {
+	SlackSlashCommandHandler
+	SensitiveArguments() bool
+}
+
+// HistoryEntry is a command a user ran
+type HistoryEntry struct {
+	Command   string
+	Arguments []string
+	At        time.Time
+}
+
+// CommandHistory remembers the most recent commands of each user, in
+// memory, so that the HistoryHandler can show users what they ran
+type CommandHistory struct {
+	size  int
+	now   func() time.Time
+	lock  sync.Mutex
+	users map[string]*historyRing
+}
+
+// historyRing holds the latest entries of a user, overwriting the oldest
+// once it is full
+type historyRing struct {
+	entries []HistoryEntry
+	next    int
+}
+
+// NewCommandHistory remembers up to size commands for each user, 20 when
+// size isn't positive
+func NewCommandHistory(size int) *CommandHistory {
+	if size <= 0 {
+		size = defaultHistorySize
+	}
+
+	return &CommandHistory{
+		size:  size,
+		now:   time.Now,
+		users: map[string]*historyRing{},
+	}
+}
+
+// record adds a command run by the user, with its arguments redacted if
+// the handler considers them sensitive
+func (h *CommandHistory) record(handler SlackSlashCommandHandler, arguments []string, request SlackSlashCommandBody) {
+	if sensitiveHandler, ok := handler.(SlackSlashCommandSensitiveHandler); ok && sensitiveHandler.SensitiveArguments() && len(arguments) > 0 {
+		arguments = []string{redacted}
+	}
+	entry := HistoryEntry{handler.CommandName(), append([]string{}, arguments...), h.now()}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	ring, ok := h.users[request.UserID]
+	if !ok {
+		ring = &historyRing{}
+		h.users[request.UserID] = ring
+	}
+	if len(ring.entries) < h.size {
+		ring.entries = append(ring.entries, entry)
+		return
+	}
+	ring.entries[ring.next] = entry
+	ring.next = (ring.next + 1) % h.size
+}
+
+// Recent returns the commands the user ran, oldest first
+func (h *CommandHistory) Recent(userID string) []HistoryEntry {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	ring, ok := h.users[userID]
+	if !ok {
+		return []HistoryEntry{}
+	}
+
+	return append(append([]HistoryEntry{}, ring.entries[ring.next:]...), ring.entries[:ring.next]...)
+}
+
+// HistoryHandler shows users the commands they recently ran, as
+// remembered by a CommandHistory
+type HistoryHandler struct {
+	history *CommandHistory
+}
+
+func NewHistoryHandler(history *CommandHistory) SlackSlashCommandHandler {
+	return HistoryHandler{history}
+}
+
+func (h HistoryHandler) Handle(arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	entries := h.history.Recent(request.UserID)
+	if len(entries) == 0 {
+		return &SlackResponse{
+			ResponseType: "ephemeral",
+			Text:         "You haven't run any commands yet",
+		}, nil
+	}
+
+	// Show when each command ran in the user's timezone, falling back to
+	// UTC for clients that can't
+	lines := []string{"Your recent commands:"}
+	for _, entry := range entries {
+		command := strings.TrimSpace(entry.Command + " " + strings.Join(entry.Arguments, " "))
+		lines = append(lines, fmt.Sprintf("• `%s` <!date^%d^{date_short_pretty} at {time}|%s>", command, entry.At.Unix(), entry.At.UTC().Format(time.RFC1123)))
+	}
+
+	return &SlackResponse{
+		ResponseType: "ephemeral",
+		Text:         strings.Join(lines, "\n"),
+	}, nil
+}
+
+func (h HistoryHandler) CommandName() string {
+	return historyCommandName
+}
+
+func (h HistoryHandler) CommandArguments() string {
+	return ""
+}
+
+func (h HistoryHandler) CommandDescription() string {
+	return "Shows the commands you recently ran"
+}
@@ -0,0 +1,77 @@
+package slack
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+type sensitiveHandler struct {
+	recordingHandler
+}
+
+func (h sensitiveHandler) SensitiveArguments() bool {
+	return true
+}
+
+func TestHistoryShowsCommandsInOrder(t *testing.T) {
+	server, responses := newResponseServer(t)
+	history := NewCommandHistory(10)
+	var arguments []string
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{
+		recordingHandler{"echo", &arguments},
+		recordingHandler{"whoami", &arguments},
+		NewHistoryHandler(history),
+	}, WithCommandHistory(history))
+	send := func(text string) SlackResponse {
+		handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+			"text":         {text},
+			"user_id":      {"U1"},
+			"response_url": {server.URL},
+		}))
+		return receiveResponse(t, responses)
+	}
+
+	send("echo hello")
+	send("whoami")
+	response := send("history")
+
+	echo := strings.Index(response.Text, "• `echo hello` <!date^")
+	whoami := strings.Index(response.Text, "• `whoami` <!date^")
+	if echo < 0 || whoami < 0 || echo > whoami {
+		t.Errorf("expected echo then whoami in the history, got %q", response.Text)
+	}
+	if strings.Contains(response.Text, "`history`") {
+		t.Errorf("expected looking at the history not to be recorded, got %q", response.Text)
+	}
+}
+
+func TestHistoryKeepsTheLatestCommands(t *testing.T) {
+	history := NewCommandHistory(2)
+	var arguments []string
+	for _, name := range []string{"first", "second", "third"} {
+		history.record(recordingHandler{name, &arguments}, []string{}, SlackSlashCommandBody{UserID: "U1"})
+	}
+	history.record(recordingHandler{"other", &arguments}, []string{}, SlackSlashCommandBody{UserID: "U2"})
+
+	commands := []string{}
+	for _, entry := range history.Recent("U1") {
+		commands = append(commands, entry.Command)
+	}
+	if !reflect.DeepEqual(commands, []string{"second", "third"}) {
+		t.Errorf("expected the two latest commands of U1, got %v", commands)
+	}
+}
+
+func TestHistoryRedactsSensitiveArguments(t *testing.T) {
+	history := NewCommandHistory(2)
+	history.record(sensitiveHandler{recordingHandler{name: "login"}}, []string{"hunter2"}, SlackSlashCommandBody{UserID: "U1"})
+
+	if entries := history.Recent("U1"); !reflect.DeepEqual(entries[0].Arguments, []string{"[redacted]"}) {
+		t.Errorf("expected the arguments to be redacted, got %v", entries[0].Arguments)
+	}
+}
@@ -0,0 +1,111 @@
+package slack
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// SlackSlashCommandIdempotentHandler may be implemented by handlers for
+// commands with side effects. Identical commands, run by the same user
+// with the same arguments within IdempotencyWindow of each other, such
+// as an accidental double submission, only run once, and the duplicates
+// get the response of the first.
+type SlackSlashCommandIdempotentHandler interface {
+	SlackSlashCommandHandler
+	IdempotencyWindow() time.Duration
+}
+
+// SlackSlashCommandIdempotencyKeyHandler may be implemented by idempotent
+// handlers that decide themselves which commands are duplicates, commands
+// with the same non-empty key are. An empty key runs the command without
+// deduplication.
+type SlackSlashCommandIdempotencyKeyHandler interface {
+	SlackSlashCommandIdempotentHandler
+	IdempotencyKey(arguments []string, request SlackSlashCommandBody) string
+}
+
+// idempotentExecution is a command run by an idempotent handler, done is
+// closed once its response is known
+type idempotentExecution struct {
+	startedAt time.Time
+	window    time.Duration
+	done      chan struct{}
+	response  *SlackResponse
+	err       error
+}
+
+// IdempotencyCache remembers the executions of idempotent handlers for
+// their window so that duplicates can be answered from it
+type IdempotencyCache struct {
+	lock       sync.Mutex
+	executions map[string]*idempotentExecution
+}
+
+func NewIdempotencyCache() *IdempotencyCache {
+	return &IdempotencyCache{
+		executions: map[string]*idempotentExecution{},
+	}
+}
+
+// idempotencyKey identifies the command for deduplication, returning an
+// empty key when it isn't deduplicated
+func idempotencyKey(handler SlackSlashCommandHandler, arguments []string, request SlackSlashCommandBody) (string, time.Duration) {
+	idempotentHandler, ok := handler.(SlackSlashCommandIdempotentHandler)
+	if !ok || idempotentHandler.IdempotencyWindow() <= 0 {
+		return "", 0
+	}
+	window := idempotentHandler.IdempotencyWindow()
+
+	if keyHandler, ok := handler.(SlackSlashCommandIdempotencyKeyHandler); ok {
+		key := keyHandler.IdempotencyKey(arguments, request)
+		if len(key) == 0 {
+			return "", 0
+		}
+		return handler.CommandName() + "\x00" + key, window
+	}
+
+	return strings.Join(append([]string{request.TeamID, request.UserID, handler.CommandName()}, arguments...), "\x00"), window
+}
+
+// run calls invoke unless an identical command ran within its handler's
+// window, in which case it returns that command's response, waiting for
+// it if it is still running. Failed commands aren't remembered, so that
+// they can be tried again.
+func (c *IdempotencyCache) run(handler SlackSlashCommandHandler, arguments []string, request SlackSlashCommandBody, invoke func() (*SlackResponse, error)) (response *SlackResponse, duplicate bool, err error) {
+	key, window := idempotencyKey(handler, arguments, request)
+	if c == nil || len(key) == 0 {
+		response, err = invoke()
+		return response, false, err
+	}
+
+	c.lock.Lock()
+
+	// Forget executions whose window has passed
+	now := time.Now()
+	for executionKey, execution := range c.executions {
+		if now.Sub(execution.startedAt) > execution.window {
+			delete(c.executions, executionKey)
+		}
+	}
+
+	execution, ok := c.executions[key]
+	if ok {
+		c.lock.Unlock()
+		<-execution.done
+		return execution.response, true, execution.err
+	}
+	execution = &idempotentExecution{startedAt: now, window: window, done: make(chan struct{})}
+	c.executions[key] = execution
+	c.lock.Unlock()
+
+	execution.response, execution.err = invoke()
+	if execution.err != nil {
+		c.lock.Lock()
+		delete(c.executions, key)
+		c.lock.Unlock()
+	}
+	close(execution.done)
+
+	return execution.response, false, execution.err
+}
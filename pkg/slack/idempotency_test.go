@@ -0,0 +1,82 @@
+package slack
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type countingIdempotentHandler struct {
+	recordingHandler
+	runs *atomic.Int32
+}
+
+func (h countingIdempotentHandler) Handle(arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	h.runs.Add(1)
+	return h.recordingHandler.Handle(arguments, request)
+}
+
+func (h countingIdempotentHandler) IdempotencyWindow() time.Duration {
+	return time.Minute
+}
+
+func TestIdempotentCommandRunsOnce(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	runs := &atomic.Int32{}
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{countingIdempotentHandler{recordingHandler{"deploy", &arguments}, runs}})
+	submit := func(userID string, triggerID string) {
+		handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+			"text":         {"deploy svcA"},
+			"user_id":      {userID},
+			"trigger_id":   {triggerID},
+			"response_url": {server.URL},
+		}))
+	}
+
+	// The same command submitted twice runs once, both get its response
+	submit("U1", "T1")
+	submit("U1", "T2")
+	for i := 0; i < 2; i++ {
+		if response := receiveResponse(t, responses); response.Text != "deploy" {
+			t.Errorf("unexpected response %q", response.Text)
+		}
+	}
+	if runs.Load() != 1 {
+		t.Errorf("expected the command to run once, it ran %d times", runs.Load())
+	}
+
+	// Another user running it isn't a duplicate
+	submit("U2", "T3")
+	receiveResponse(t, responses)
+	if runs.Load() != 2 {
+		t.Errorf("expected another user's command to run, it ran %d times", runs.Load())
+	}
+}
+
+func TestIdempotencyCacheIsSharedBetweenBots(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	runs := &atomic.Int32{}
+	handlers := []SlackSlashCommandHandler{countingIdempotentHandler{recordingHandler{"deploy", &arguments}, runs}}
+	cache := NewIdempotencyCache()
+
+	// A bot replacing another with the same cache catches its duplicates
+	for i, triggerID := range []string{"T1", "T2"} {
+		handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), handlers, WithIdempotencyCache(cache))
+		handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+			"text":         {"deploy svcA"},
+			"user_id":      {"U1"},
+			"trigger_id":   {triggerID},
+			"response_url": {server.URL},
+		}))
+		receiveResponse(t, responses)
+		if runs.Load() != 1 {
+			t.Errorf("expected the command to run once after %d submissions, it ran %d times", i+1, runs.Load())
+		}
+	}
+}
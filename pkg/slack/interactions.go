@@ -0,0 +1,91 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// BlockAction is a single interaction with an element of a message, such
+// as a button click
+type BlockAction struct {
+	Type     string `json:"type"`
+	ActionID string `json:"action_id"`
+	BlockID  string `json:"block_id"`
+	Value    string `json:"value"`
+}
+
+type InteractionUser struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	TeamID   string `json:"team_id"`
+}
+
+type InteractionChannel struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type InteractionMessage struct {
+	Text   string  `json:"text"`
+	Blocks []Block `json:"blocks"`
+}
+
+// BlockActionsPayload is sent by Slack, as the payload form field, when a
+// user interacts with the elements of a message posted by the bot
+type BlockActionsPayload struct {
+	Type        string             `json:"type"`
+	TriggerID   string             `json:"trigger_id"`
+	ResponseURL string             `json:"response_url"`
+	User        InteractionUser    `json:"user"`
+	Channel     InteractionChannel `json:"channel"`
+	Actions     []BlockAction      `json:"actions"`
+	Message     InteractionMessage `json:"message"`
+}
+
+// ParseBlockActions decodes the payload form field of a block_actions
+// interaction
+func ParseBlockActions(payload string) (BlockActionsPayload, error) {
+	var actions BlockActionsPayload
+	err := json.Unmarshal([]byte(payload), &actions)
+	if err != nil {
+		return BlockActionsPayload{}, fmt.Errorf("could not decode interaction payload: %w", err)
+	}
+	if actions.Type != "block_actions" {
+		return BlockActionsPayload{}, fmt.Errorf("expected a block_actions interaction, got %q", actions.Type)
+	}
+
+	return actions, nil
+}
+
+// UpdatedMessage builds a copy of the interacted message in which each
+// block sharing its block ID with one of the replacements is replaced by
+// it, preserving every other block and the order of all blocks. Posting it
+// replaces the original message.
+func (p BlockActionsPayload) UpdatedMessage(replacements ...Block) *SlackResponse {
+	replacementsByID := map[string]Block{}
+	for _, replacement := range replacements {
+		replacementsByID[replacement.BlockID] = replacement
+	}
+
+	blocks := make([]Block, len(p.Message.Blocks))
+	for i, block := range p.Message.Blocks {
+		replacement, ok := replacementsByID[block.BlockID]
+		if ok && len(block.BlockID) > 0 {
+			block = replacement
+		}
+		blocks[i] = block
+	}
+
+	return &SlackResponse{
+		Text:            p.Message.Text,
+		Blocks:          blocks,
+		ReplaceOriginal: true,
+	}
+}
+
+// Update replaces the interacted message with UpdatedMessage through the
+// interaction's response_url, for instance to show a toggled selection
+func (p BlockActionsPayload) Update(ctx context.Context, responder Responder, replacements ...Block) error {
+	return responder.Deliver(ctx, p.ResponseURL, p.UpdatedMessage(replacements...))
+}
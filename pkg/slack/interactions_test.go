@@ -0,0 +1,96 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const toggleInteraction = `{
+	"type": "block_actions",
+	"trigger_id": "123.456",
+	"response_url": "https://hooks.slack.com/actions/T1/1/abc",
+	"user": {"id": "U123", "username": "jo"},
+	"actions": [{"type": "button", "action_id": "toggle", "block_id": "selection", "value": "off"}],
+	"message": {
+		"text": "Notifications",
+		"blocks": [
+			{"type": "section", "block_id": "intro", "text": {"type": "mrkdwn", "text": "Get notified?"}},
+			{"type": "image", "block_id": "banner", "image_url": "https://example.com/banner.png", "alt_text": "banner"},
+			{"type": "actions", "block_id": "selection", "elements": [
+				{"type": "button", "action_id": "toggle", "text": {"type": "plain_text", "text": "Off"}, "value": "off"}
+			]}
+		]
+	}
+}`
+
+func TestToggleInteractionReplacesMessage(t *testing.T) {
+	payload, err := ParseBlockActions(toggleInteraction)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(payload.Actions) != 1 || payload.Actions[0].Value != "off" {
+		t.Fatalf("unexpected actions %+v", payload.Actions)
+	}
+
+	// Flip the toggle and replace the message
+	toggled := NewButtonElement("toggle", "On", "on")
+	toggled.Style = "primary"
+	responder := fakeResponder{make(chan deliveredResponse, 1)}
+	err = payload.Update(context.Background(), responder, NewActionsBlock("selection", toggled))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	delivered := <-responder.deliveries
+	if delivered.target != payload.ResponseURL {
+		t.Errorf("expected delivery to the interaction's response_url, got %q", delivered.target)
+	}
+	encoded, err := json.Marshal(delivered.response)
+	if err != nil {
+		t.Fatalf("could not encode response: %v", err)
+	}
+	var message struct {
+		ReplaceOriginal bool                     `json:"replace_original"`
+		Text            string                   `json:"text"`
+		Blocks          []map[string]interface{} `json:"blocks"`
+	}
+	json.Unmarshal(encoded, &message)
+	if !message.ReplaceOriginal || message.Text != "Notifications" {
+		t.Errorf("expected the original message to be replaced, got %s", encoded)
+	}
+	if len(message.Blocks) != 3 {
+		t.Fatalf("expected all three blocks, got %s", encoded)
+	}
+
+	// Other blocks are preserved as received, including unmodelled fields
+	if message.Blocks[0]["block_id"] != "intro" || message.Blocks[1]["image_url"] != "https://example.com/banner.png" {
+		t.Errorf("expected the other blocks to be preserved, got %s", encoded)
+	}
+	button := message.Blocks[2]["elements"].([]interface{})[0].(map[string]interface{})
+	if button["value"] != "on" || button["style"] != "primary" {
+		t.Errorf("expected the toggle to reflect its new state, got %v", button)
+	}
+}
+
+func TestParseBlockActionsRejectsOtherInteractions(t *testing.T) {
+	_, err := ParseBlockActions(`{"type": "view_submission"}`)
+	if err == nil || !strings.Contains(err.Error(), "view_submission") {
+		t.Errorf("expected other interaction types to be rejected, got %v", err)
+	}
+}
+
+func TestModifiedBlocksAreReencoded(t *testing.T) {
+	var block Block
+	err := json.Unmarshal([]byte(`{"type": "section", "block_id": "intro", "text": {"type": "mrkdwn", "text": "before"}, "accessory": {"type": "image"}}`), &block)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	block.Text = NewMarkdownText("after")
+
+	encoded, _ := json.Marshal(block)
+	if !strings.Contains(string(encoded), "after") {
+		t.Errorf("expected the modified text to be encoded, got %s", encoded)
+	}
+}
@@ -0,0 +1,60 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+// Commands with more arguments or longer text than this are rejected
+// before reaching a handler by default
+const (
+	defaultMaxArguments  = 100
+	defaultMaxTextLength = 4000
+)
+
+// textLimitExceeded reports why the command's text is too long, if it is,
+// so that it is rejected before being parsed
+func textLimitExceeded(opts slackBotOptions, request SlackSlashCommandBody) string {
+	maxTextLength := opts.maxTextLength
+	if maxTextLength <= 0 {
+		maxTextLength = defaultMaxTextLength
+	}
+	if length := utf8.RuneCountInString(request.Text); length > maxTextLength {
+		return fmt.Sprintf("That command is too long, it can be at most %d characters but was %d", maxTextLength, length)
+	}
+
+	return ""
+}
+
+// argumentLimitExceeded reports why the command has too many arguments,
+// if it does
+func argumentLimitExceeded(opts slackBotOptions, arguments []string) string {
+	maxArguments := opts.maxArguments
+	if maxArguments <= 0 {
+		maxArguments = defaultMaxArguments
+	}
+	if len(arguments) > maxArguments {
+		return fmt.Sprintf("That command has too many arguments, it can have at most %d but had %d", maxArguments, len(arguments))
+	}
+
+	return ""
+}
+
+// respondOverLimit lets the user know why their command was rejected
+func respondOverLimit(logger *zap.Logger, responder Responder, responseURL string, reason string) {
+	logger.Warn("rejecting command over the limits", zap.String("reason", reason))
+	if len(responseURL) == 0 {
+		return
+	}
+
+	err := responder.Deliver(context.Background(), responseURL, &SlackResponse{
+		ResponseType: "ephemeral",
+		Text:         reason,
+	})
+	if err != nil {
+		logger.Error("could not send over-limit message", zap.Error(err))
+	}
+}
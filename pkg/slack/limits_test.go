@@ -0,0 +1,50 @@
+package slack
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestCommandsWithTooManyArgumentsAreRejected(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}}, WithArgumentLimits(3, 0))
+
+	r := newSignedRequest("abc", url.Values{
+		"text":         {"echo a b c d"},
+		"response_url": {server.URL},
+	})
+	handler(httptest.NewRecorder(), r)
+
+	response := receiveResponse(t, responses)
+	if response.ResponseType != "ephemeral" || !strings.Contains(response.Text, "too many arguments") {
+		t.Errorf("expected an ephemeral rejection, got %+v", response)
+	}
+	if arguments != nil {
+		t.Errorf("expected the handler not to run, it got %v", arguments)
+	}
+}
+
+func TestCommandsWithTooMuchTextAreRejected(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}}, WithArgumentLimits(0, 10))
+
+	r := newSignedRequest("abc", url.Values{
+		"text":         {"echo " + strings.Repeat("x", 10)},
+		"response_url": {server.URL},
+	})
+	handler(httptest.NewRecorder(), r)
+
+	response := receiveResponse(t, responses)
+	if response.ResponseType != "ephemeral" || !strings.Contains(response.Text, "too long") {
+		t.Errorf("expected an ephemeral rejection, got %+v", response)
+	}
+	if arguments != nil {
+		t.Errorf("expected the handler not to run, it got %v", arguments)
+	}
+}
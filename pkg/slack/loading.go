@@ -0,0 +1,61 @@
+package slack
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// SlackSlashCommandLoadingHandler may be implemented by handlers running
+// in the background, typically deferred ones, to post a loading message
+// such as "⏳ Working…" to the response_url before they start. The
+// handler's response then replaces it, or it is posted as a new message
+// if the loading message couldn't be delivered. An empty LoadingMessage
+// posts nothing.
+type SlackSlashCommandLoadingHandler interface {
+	SlackSlashCommandHandler
+	LoadingMessage(arguments []string, request SlackSlashCommandBody) string
+}
+
+type loadingMessageContextKey struct{}
+
+// postLoadingMessage posts the handler's loading message, if any,
+// returning a context telling dispatch to replace it with the response
+func postLoadingMessage(ctx context.Context, logger *zap.Logger, opts slackBotOptions, handler SlackSlashCommandHandler, arguments []string, request SlackSlashCommandBody) context.Context {
+	loadingHandler, ok := handler.(SlackSlashCommandLoadingHandler)
+	if !ok || len(request.ResponseURL) == 0 {
+		return ctx
+	}
+	text := loadingHandler.LoadingMessage(arguments, request)
+	if len(text) == 0 {
+		return ctx
+	}
+
+	err := opts.responder.Deliver(context.WithoutCancel(ctx), request.ResponseURL, &SlackResponse{
+		ResponseType: "ephemeral",
+		Text:         text,
+	})
+	if err != nil {
+		logger.Error("could not send loading message, the response will be posted as a new message", zap.Error(err))
+		return ctx
+	}
+
+	return context.WithValue(ctx, loadingMessageContextKey{}, true)
+}
+
+// replaceLoadingMessage makes the response replace the loading message,
+// if one was posted, replacing it with a short note when the handler has
+// nothing to say so that it doesn't look like it is still working
+func replaceLoadingMessage(ctx context.Context, response *SlackResponse) *SlackResponse {
+	if posted, _ := ctx.Value(loadingMessageContextKey{}).(bool); !posted {
+		return response
+	}
+	if response == nil {
+		return &SlackResponse{ResponseType: "ephemeral", Text: "Done", ReplaceOriginal: true}
+	}
+
+	replacing := *response
+	replacing.ReplaceOriginal = true
+
+	return &replacing
+}
@@ -0,0 +1,99 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type slowHandler struct{}
+
+func (h slowHandler) Handle(arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	return &SlackResponse{ResponseType: "in_channel", Text: "report ready"}, nil
+}
+
+func (h slowHandler) Deferred() bool {
+	return true
+}
+
+func (h slowHandler) LoadingMessage(arguments []string, request SlackSlashCommandBody) string {
+	return "⏳ Working…"
+}
+
+func (h slowHandler) CommandName() string {
+	return "report"
+}
+
+func (h slowHandler) CommandArguments() string {
+	return ""
+}
+
+func (h slowHandler) CommandDescription() string {
+	return ""
+}
+
+func TestLoadingMessageIsReplacedByTheResponse(t *testing.T) {
+	responder := fakeResponder{make(chan deliveredResponse, 2)}
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{slowHandler{}}, WithResponder(responder))
+
+	handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+		"text":         {"report"},
+		"response_url": {"https://hooks.slack.com/commands/T1/1/abc"},
+	}))
+
+	expected := []SlackResponse{
+		{ResponseType: "ephemeral", Text: "⏳ Working…"},
+		{ResponseType: "in_channel", Text: "report ready", ReplaceOriginal: true},
+	}
+	for _, response := range expected {
+		select {
+		case delivered := <-responder.deliveries:
+			if !reflect.DeepEqual(*delivered.response, response) {
+				t.Errorf("expected %+v, got %+v", response, *delivered.response)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected %+v to be delivered", response)
+		}
+	}
+}
+
+// firstFailingResponder fails its first delivery and passes the others on
+type firstFailingResponder struct {
+	failed     chan struct{}
+	deliveries chan deliveredResponse
+}
+
+func (f firstFailingResponder) Deliver(ctx context.Context, target string, response *SlackResponse) error {
+	select {
+	case f.failed <- struct{}{}:
+		return errors.New("slack is unavailable")
+	default:
+	}
+	f.deliveries <- deliveredResponse{target, response}
+	return nil
+}
+
+func TestResponseIsPostedWhenTheLoadingMessageFails(t *testing.T) {
+	responder := firstFailingResponder{make(chan struct{}, 1), make(chan deliveredResponse, 1)}
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{slowHandler{}}, WithResponder(responder))
+
+	handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+		"text":         {"report"},
+		"response_url": {"https://hooks.slack.com/commands/T1/1/abc"},
+	}))
+
+	select {
+	case delivered := <-responder.deliveries:
+		if delivered.response.Text != "report ready" || delivered.response.ReplaceOriginal {
+			t.Errorf("expected the response as a new message, got %+v", delivered.response)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the response to be delivered")
+	}
+}
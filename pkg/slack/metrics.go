@@ -0,0 +1,117 @@
+package slack
+
+import (
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	responseDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "slack_bot_response_deliveries_total",
+		Help: "Responses posted to Slack response_urls, by outcome",
+	}, []string{"outcome"})
+	responseDeliveryDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "slack_bot_response_delivery_duration_seconds",
+		Help:    "Time taken to post responses to Slack response_urls",
+		Buckets: prometheus.DefBuckets,
+	})
+	handlerInvocations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "slack_bot_handler_invocations_total",
+		Help: "Handler invocations by command and outcome, either success, error or panic",
+	}, []string{"command", "outcome"})
+	breakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "slack_bot_circuit_breaker_state",
+		Help: "State of circuit breakers around calls to Slack, 0 when closed, 1 when half-open, and 2 when open",
+	}, []string{"name"})
+	degradedFeatures = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "slack_bot_degraded",
+		Help: "Whether a feature is degraded because something it depends on is unavailable, 1 when degraded",
+	}, []string{"feature"})
+)
+
+// MetricsRegisterer may be implemented by handlers that expose their own
+// metrics, such as a count of deploys. RegisterMetrics is called when the
+// handler is added to a bot, and the collectors it registers are served
+// alongside the bot's own metrics. Since handlers are recreated when the
+// configuration is reloaded, collectors should be created once, such as
+// in package variables, registering the same collector again is ignored.
+type MetricsRegisterer interface {
+	SlackSlashCommandHandler
+	RegisterMetrics(registerer prometheus.Registerer)
+}
+
+// reregisteringRegisterer ignores attempts to register a collector that
+// is already registered, so that handlers can register theirs every time
+// they are added to a bot
+type reregisteringRegisterer struct {
+	prometheus.Registerer
+}
+
+func (r reregisteringRegisterer) Register(collector prometheus.Collector) error {
+	err := r.Registerer.Register(collector)
+	var alreadyRegistered prometheus.AlreadyRegisteredError
+	if errors.As(err, &alreadyRegistered) && alreadyRegistered.ExistingCollector == collector {
+		return nil
+	}
+
+	return err
+}
+
+func (r reregisteringRegisterer) MustRegister(collectors ...prometheus.Collector) {
+	for _, collector := range collectors {
+		if err := r.Register(collector); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// registerHandlerMetrics lets every handler that has metrics register them
+func registerHandlerMetrics(registerer prometheus.Registerer, handlers []SlackSlashCommandHandler) {
+	for _, handler := range handlers {
+		if metricsHandler, ok := unwrapNamespace(handler).(MetricsRegisterer); ok {
+			metricsHandler.RegisterMetrics(reregisteringRegisterer{registerer})
+		}
+	}
+}
+
+// deliveryOutcome classifies the result of posting a response for metrics
+func deliveryOutcome(err error) string {
+	if err == nil {
+		return "success"
+	}
+	if errors.Is(err, ErrCircuitOpen) {
+		return "circuit_open"
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return "timeout"
+	}
+
+	var statusErr *ResponseStatusError
+	if errors.As(err, &statusErr) {
+		if strings.Contains(statusErr.Reason, "expired_url") {
+			return "expired_url"
+		}
+		return "non_2xx"
+	}
+
+	return "error"
+}
+
+// isDegradationFailure reports whether an error from Slack suggests that
+// Slack itself is degraded, as opposed to a problem with a single request
+// such as an expired response_url
+func isDegradationFailure(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	var statusErr *ResponseStatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode >= 500
+}
@@ -0,0 +1,126 @@
+package slack
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+)
+
+func TestRespondCountsFailures(t *testing.T) {
+	tests := []struct {
+		status  int
+		body    string
+		outcome string
+	}{
+		{http.StatusOK, "ok", "success"},
+		{http.StatusInternalServerError, "", "non_2xx"},
+		{http.StatusNotFound, "expired_url", "expired_url"},
+	}
+
+	for _, test := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(test.status)
+			w.Write([]byte(test.body))
+		}))
+		before := testutil.ToFloat64(responseDeliveries.WithLabelValues(test.outcome))
+
+		err := Respond(server.URL, &SlackResponse{Text: "hi"})
+		server.Close()
+		if (err == nil) != (test.outcome == "success") {
+			t.Errorf("unexpected error for status %d: %v", test.status, err)
+		}
+
+		after := testutil.ToFloat64(responseDeliveries.WithLabelValues(test.outcome))
+		if after != before+1 {
+			t.Errorf("expected the %s counter to increment, went from %v to %v", test.outcome, before, after)
+		}
+	}
+}
+
+type panickingHandler struct {
+	recordingHandler
+}
+
+func (h panickingHandler) Handle(arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	var handlers []SlackSlashCommandHandler
+	return handlers[len(arguments)].Handle(arguments, request)
+}
+
+func TestHandlerPanicsAreRecoveredAndCounted(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{
+		panickingHandler{recordingHandler{name: "explode"}},
+		recordingHandler{"echo", &arguments},
+	})
+	panics := testutil.ToFloat64(handlerInvocations.WithLabelValues("explode", "panic"))
+	successes := testutil.ToFloat64(handlerInvocations.WithLabelValues("echo", "success"))
+
+	handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+		"text":         {"explode"},
+		"response_url": {server.URL},
+	}))
+	response := receiveResponse(t, responses)
+	if response.ResponseType != "ephemeral" || response.Text != ErrHandlerPanicked.Error() {
+		t.Errorf("expected an ephemeral internal error, got %q as %s", response.Text, response.ResponseType)
+	}
+	if count := testutil.ToFloat64(handlerInvocations.WithLabelValues("explode", "panic")); count != panics+1 {
+		t.Errorf("expected the panic to be counted, got %v", count)
+	}
+
+	// The bot keeps handling commands
+	handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+		"text":         {"echo"},
+		"response_url": {server.URL},
+	}))
+	if response := receiveResponse(t, responses); response.Text != "echo" {
+		t.Errorf("unexpected response %q after a panic", response.Text)
+	}
+	if count := testutil.ToFloat64(handlerInvocations.WithLabelValues("echo", "success")); count != successes+1 {
+		t.Errorf("expected the success to be counted, got %v", count)
+	}
+}
+
+var testDeploys = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "test_deploys_total",
+	Help: "Deploys made by the test handler",
+})
+
+type metricsHandler struct {
+	recordingHandler
+}
+
+func (h metricsHandler) RegisterMetrics(registerer prometheus.Registerer) {
+	registerer.MustRegister(testDeploys)
+}
+
+func TestHandlerMetricsAreExposed(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	var arguments []string
+	handlers := []SlackSlashCommandHandler{metricsHandler{recordingHandler{"deploy", &arguments}}}
+	bot := NewSlackBot(0, NewStaticSecretSource("abc", ""), handlers, WithMetricsRegisterer(registry))
+	testDeploys.Inc()
+
+	// Adding the handler again, as a reload does, doesn't fail
+	bot.SetHandlers(handlers)
+
+	server := httptest.NewServer(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	defer server.Close()
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("could not fetch metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "test_deploys_total 1") {
+		t.Errorf("expected the handler's counter in the metrics output, got %q", body)
+	}
+}
@@ -0,0 +1,95 @@
+package slack
+
+import (
+	"sort"
+	"strings"
+)
+
+// Separates the namespace from the command, as in `team1:deploy`, by
+// default
+const defaultNamespaceSeparator = ":"
+
+// SlackSlashCommandNamespacedHandler may be implemented by handlers that
+// belong to a namespace, such as the commands of one of the teams sharing
+// an app. They are run as `<namespace><separator><command>`, for example
+// `team1:deploy`, so that several namespaces can have the same commands.
+type SlackSlashCommandNamespacedHandler interface {
+	SlackSlashCommandHandler
+	Namespace() string
+}
+
+type namespacedHandler struct {
+	SlackSlashCommandHandler
+	namespace string
+}
+
+func (h namespacedHandler) Namespace() string {
+	return h.namespace
+}
+
+// NewNamespace puts handlers in the given namespace. Every optional
+// interface they implement keeps working once they are routed to.
+func NewNamespace(namespace string, handlers ...SlackSlashCommandHandler) []SlackSlashCommandHandler {
+	namespaced := make([]SlackSlashCommandHandler, len(handlers))
+	for i, handler := range handlers {
+		namespaced[i] = namespacedHandler{handler, namespace}
+	}
+
+	return namespaced
+}
+
+// namespaceOf returns the handler's namespace, empty when it has none
+func namespaceOf(handler SlackSlashCommandHandler) string {
+	if namespaced, ok := handler.(SlackSlashCommandNamespacedHandler); ok {
+		return namespaced.Namespace()
+	}
+
+	return ""
+}
+
+// unwrapNamespace returns the handler put in a namespace by NewNamespace,
+// so that its optional interfaces can be used
+func unwrapNamespace(handler SlackSlashCommandHandler) SlackSlashCommandHandler {
+	if namespaced, ok := handler.(namespacedHandler); ok {
+		return namespaced.SlackSlashCommandHandler
+	}
+
+	return handler
+}
+
+// splitNamespace splits a command into its namespace and the command
+// within it, the namespace is empty when the command has none
+func splitNamespace(command string, separator string) (string, string) {
+	if len(separator) == 0 {
+		return "", command
+	}
+	namespace, name, found := strings.Cut(command, separator)
+	if !found {
+		return "", command
+	}
+
+	return namespace, name
+}
+
+// qualifiedName is the name the handler is run with, including its
+// namespace
+func qualifiedName(handler SlackSlashCommandHandler, separator string) string {
+	namespace := namespaceOf(handler)
+	if len(namespace) == 0 {
+		return handler.CommandName()
+	}
+
+	return namespace + separator + handler.CommandName()
+}
+
+// groupByNamespace orders handlers so that those without a namespace come
+// first, followed by each namespace in turn, keeping their order within
+// a namespace
+func groupByNamespace(handlers []SlackSlashCommandHandler) []SlackSlashCommandHandler {
+	grouped := append([]SlackSlashCommandHandler{}, handlers...)
+	sort.SliceStable(grouped, func(i, j int) bool {
+		return namespaceOf(grouped[i]) < namespaceOf(grouped[j])
+	})
+
+	return grouped
+}
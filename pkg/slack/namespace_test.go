@@ -0,0 +1,65 @@
+package slack
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestNamespacedCommandsAreRoutedToTheirNamespace(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var team1Arguments, team2Arguments []string
+	handlers := append(
+		NewNamespace("team1", recordingHandler{"echo", &team1Arguments}),
+		NewNamespace("team2", recordingHandler{"echo", &team2Arguments})...,
+	)
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), handlers)
+
+	send := func(text string) {
+		handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+			"text":         {text},
+			"response_url": {server.URL},
+		}))
+	}
+
+	send("team1:echo hello")
+	receiveResponse(t, responses)
+	if strings.Join(team1Arguments, " ") != "hello" || team2Arguments != nil {
+		t.Errorf("expected team1:echo to reach team1's echo only, got %v and %v", team1Arguments, team2Arguments)
+	}
+
+	send("team2:echo bonjour")
+	receiveResponse(t, responses)
+	if strings.Join(team2Arguments, " ") != "bonjour" || strings.Join(team1Arguments, " ") != "hello" {
+		t.Errorf("expected team2:echo to reach team2's echo only, got %v and %v", team1Arguments, team2Arguments)
+	}
+}
+
+func TestHelpGroupsCommandsByNamespace(t *testing.T) {
+	handlers := append(
+		NewNamespace("team2", describedHandler{"deploy", "<service>", "Deploys team2's services"}),
+		describedHandler{"echo", "[words...]", "Echoes words"},
+	)
+	handlers = append(handlers, NewNamespace("team1", describedHandler{"deploy", "<service>", "Deploys team1's services"})...)
+	help := NewHelpHandler("help", &handlers)
+
+	response, err := help.Handle([]string{}, SlackSlashCommandBody{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "echo [words...]\nEchoes words\n\nteam1\nteam1:deploy <service>\nDeploys team1's services\n\nteam2\nteam2:deploy <service>\nDeploys team2's services\n"
+	if response.Text != expected {
+		t.Errorf("expected help grouped by namespace, got %q", response.Text)
+	}
+
+	response, err = help.Handle([]string{"team1:deploy"}, SlackSlashCommandBody{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(response.Text, "Usage: team1:deploy <service>\nDeploys team1's services") {
+		t.Errorf("expected the help of team1:deploy, got %q", response.Text)
+	}
+}
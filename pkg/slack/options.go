@@ -0,0 +1,356 @@
+package slack
+
+import (
+	"net/netip"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+)
+
+type SlackBotOption func(*slackBotOptions)
+
+type slackBotOptions struct {
+	caseSensitiveCommands bool
+	helpCommandName       string
+	reconnectPolicy       ReconnectPolicy
+	commandParser         CommandParser
+	readiness             *ReadinessGate
+	allowedSourceRanges   []netip.Prefix
+	trustedProxies        []netip.Prefix
+	cancellations         *CancellationRegistry
+	allowedContentTypes   []string
+	unixSocket            string
+	requestTimeout        time.Duration
+	retryWindow           time.Duration
+	retries               *RetryDeduplicator
+	verificationToken     string
+	tracerProvider        trace.TracerProvider
+	responder             Responder
+	client                SlackAPI
+	deadLetters           DeadLetterSink
+	responsePrefix        string
+	responseSuffix        string
+	showHiddenCommands    bool
+	helpAdmins            []string
+	metricsRegisterer     prometheus.Registerer
+	idempotency           *IdempotencyCache
+	helpMessageLimit      int
+	conversations         ConversationStore
+	maxArguments          int
+	maxTextLength         int
+	namespaceSeparator    string
+	logger                *zap.Logger
+	history               *CommandHistory
+	featureFlags          FeatureFlags
+}
+
+func newSlackBotOptions(options []SlackBotOption) slackBotOptions {
+	opts := slackBotOptions{
+		helpCommandName:    "help",
+		reconnectPolicy:    DefaultReconnectPolicy,
+		commandParser:      CommandParserFunc(ParseCommand),
+		retryWindow:        defaultRetryWindow,
+		tracerProvider:     noop.NewTracerProvider(),
+		responder:          HTTPResponder{},
+		metricsRegisterer:  prometheus.DefaultRegisterer,
+		namespaceSeparator: defaultNamespaceSeparator,
+		logger:             zap.NewNop(),
+		allowedContentTypes: []string{
+			"application/x-www-form-urlencoded",
+		},
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	return opts
+}
+
+// WithCaseSensitiveCommands controls whether the command typed by the
+// user must match a handler's CommandName() exactly. By default, matching
+// ignores case so that `/bot Echo` and `/bot echo` are equivalent.
+func WithCaseSensitiveCommands(caseSensitive bool) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		opts.caseSensitiveCommands = caseSensitive
+	}
+}
+
+// WithHelpCommandName renames the built-in help command, for teams that
+// already use `help` for something else
+func WithHelpCommandName(name string) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		if len(name) > 0 {
+			opts.helpCommandName = name
+		}
+	}
+}
+
+// WithReconnectPolicy controls how the socket mode server reconnects
+// after a failed connection
+func WithReconnectPolicy(policy ReconnectPolicy) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		opts.reconnectPolicy = policy
+	}
+}
+
+// WithCommandParser replaces the default parser, which splits the text
+// on spaces, for teams that want a different command syntax
+func WithCommandParser(parser CommandParser) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		if parser != nil {
+			opts.commandParser = parser
+		}
+	}
+}
+
+// WithReadinessGate makes the bot reply 503 to every request until the
+// gate is marked ready
+func WithReadinessGate(readiness *ReadinessGate) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		opts.readiness = readiness
+	}
+}
+
+// WithAllowedSourceRanges rejects requests with a 403 unless they come
+// from one of the given ranges. No ranges, the default, allows every
+// source.
+func WithAllowedSourceRanges(ranges []netip.Prefix) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		opts.allowedSourceRanges = ranges
+	}
+}
+
+// WithTrustedProxies lists the proxies whose X-Forwarded-For header is
+// trusted when working out which address a request came from
+func WithTrustedProxies(proxies []netip.Prefix) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		opts.trustedProxies = proxies
+	}
+}
+
+// WithCancellationRegistry registers deferred commands with the registry
+// while they run, telling users the ID to pass to the CancelHandler to
+// cancel them
+func WithCancellationRegistry(registry *CancellationRegistry) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		opts.cancellations = registry
+	}
+}
+
+// WithAllowedContentTypes replaces the media types requests may use,
+// which only application/x-www-form-urlencoded by default. Media types
+// are compared without their parameters and regardless of case.
+func WithAllowedContentTypes(mediaTypes ...string) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		if len(mediaTypes) > 0 {
+			opts.allowedContentTypes = mediaTypes
+		}
+	}
+}
+
+// WithUnixSocket makes ListenAndServe serve on a unix domain socket at
+// the given path instead of the bot's port, for deployments proxying to
+// the bot from a sidecar
+func WithUnixSocket(path string) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		opts.unixSocket = path
+	}
+}
+
+// WithRequestTimeout answers requests with a 503 once they have taken
+// longer than timeout, as a safety net for handlers that don't respect
+// their context. Commands are handled within Slack's three second
+// acknowledgement window or deferred, so the timeout should be longer
+// than that window. Zero, the default, disables it.
+func WithRequestTimeout(timeout time.Duration) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		opts.requestTimeout = timeout
+	}
+}
+
+// WithRetryWindow controls how long delivered commands are remembered so
+// that Slack's retries of them, identified by their trigger_id, are
+// dropped instead of being handled twice. It is ten minutes by default,
+// and zero disables deduplication.
+func WithRetryWindow(window time.Duration) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		opts.retryWindow = window
+	}
+}
+
+// WithRetryDeduplicator remembers delivered commands with the given
+// deduplicator, using its window rather than the one set by
+// WithRetryWindow. Bots replacing each other, such as on config reloads,
+// can share one so that retries are still dropped across them. Each bot
+// has a deduplicator of its own by default.
+func WithRetryDeduplicator(deduplicator *RetryDeduplicator) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		opts.retries = deduplicator
+	}
+}
+
+// WithLegacyVerificationToken verifies requests by comparing their token
+// field with the given verification token instead of checking their
+// signature. It only takes effect when the signing key is empty.
+//
+// Deprecated: Slack has deprecated verification tokens, which can't
+// protect against replayed requests. Use a signing key instead.
+func WithLegacyVerificationToken(token string) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		opts.verificationToken = token
+	}
+}
+
+// WithTracerProvider traces each request with spans from the given
+// provider, covering verification, the handler, and the response. The
+// span around the handler is available from the context given to
+// context-aware handlers. Tracing is a no-op by default.
+func WithTracerProvider(provider trace.TracerProvider) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		if provider != nil {
+			opts.tracerProvider = provider
+		}
+	}
+}
+
+// WithResponder replaces how responses are delivered, which by default
+// posts them to their response_url
+func WithResponder(responder Responder) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		if responder != nil {
+			opts.responder = responder
+		}
+	}
+}
+
+// WithWebAPIClient gives the bot a Web API client for features that
+// can't be provided through a response_url, such as expiring errors, and
+// for handlers to get with SlackAPIFromContext. There is none by default.
+func WithWebAPIClient(client SlackAPI) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		opts.client = client
+	}
+}
+
+// WithDeadLetterSink stores responses that could not be delivered in the
+// given sink, instead of logging them at warn level
+func WithDeadLetterSink(sink DeadLetterSink) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		opts.deadLetters = sink
+	}
+}
+
+// WithResponseDecoration adds prefix before and suffix after the text of
+// every response to a command, such as an environment indicator like
+// `[staging] `. They are added verbatim, so include any separating space
+// or newline. Responses made of blocks get them as context blocks at the
+// top and bottom instead.
+func WithResponseDecoration(prefix string, suffix string) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		opts.responsePrefix = prefix
+		opts.responseSuffix = suffix
+	}
+}
+
+// WithHiddenCommandsShown lists hidden commands in help for everyone,
+// for environments such as staging where nothing needs hiding
+func WithHiddenCommandsShown(show bool) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		opts.showHiddenCommands = show
+	}
+}
+
+// WithHelpAdmins lists the IDs of the users who see hidden commands in
+// help
+func WithHelpAdmins(userIDs ...string) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		opts.helpAdmins = userIDs
+	}
+}
+
+// WithMetricsRegisterer sets where handlers implementing
+// MetricsRegisterer register their metrics, the default Prometheus
+// registry by default
+func WithMetricsRegisterer(registerer prometheus.Registerer) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		if registerer != nil {
+			opts.metricsRegisterer = registerer
+		}
+	}
+}
+
+// WithHelpMessageLimit sets how long the text of a single help message
+// may get before the list of commands is split across several messages,
+// 4000 characters by default
+func WithHelpMessageLimit(limit int) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		opts.helpMessageLimit = limit
+	}
+}
+
+// WithIdempotencyCache remembers the executions of idempotent handlers
+// in the given cache, which bots replacing each other, such as on config
+// reloads, can share so that duplicates are still caught across them.
+// Each bot has a cache of its own by default.
+func WithIdempotencyCache(cache *IdempotencyCache) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		opts.idempotency = cache
+	}
+}
+
+// WithConversationStore sets where the state of conversations is kept,
+// in memory for 15 minutes by default
+func WithConversationStore(store ConversationStore) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		opts.conversations = store
+	}
+}
+
+// WithArgumentLimits rejects commands with more than maxArguments
+// arguments or more than maxTextLength characters of text before they
+// reach a handler, 100 arguments and 4000 characters by default
+func WithArgumentLimits(maxArguments int, maxTextLength int) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		opts.maxArguments = maxArguments
+		opts.maxTextLength = maxTextLength
+	}
+}
+
+// WithNamespaceSeparator sets what separates the namespace of a command
+// from the command, ":" by default. An empty separator turns namespaces
+// off, leaving namespaced commands unreachable.
+func WithNamespaceSeparator(separator string) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		opts.namespaceSeparator = separator
+	}
+}
+
+// WithLogger sets the logger used while the bot is being set up, such as
+// for listing its commands, nothing is logged by default
+func WithLogger(logger *zap.Logger) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		if logger != nil {
+			opts.logger = logger
+		}
+	}
+}
+
+// WithCommandHistory records every command users run in history, so that
+// the HistoryHandler can show it to them
+func WithCommandHistory(history *CommandHistory) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		opts.history = history
+	}
+}
+
+// WithFeatureFlags registers the handlers gated behind a feature flag
+// only while flags enable it. Flags are checked whenever the handlers are
+// set, such as when the bot is created or its handlers are replaced.
+func WithFeatureFlags(flags FeatureFlags) SlackBotOption {
+	return func(opts *slackBotOptions) {
+		opts.featureFlags = flags
+	}
+}
@@ -0,0 +1,115 @@
+package slack
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+
+	"github.com/gorilla/websocket"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Version identifies this build of the bot in outbound requests, it can
+// be set at build time with
+// -ldflags "-X github.com/pauwels-labs/slack-bot/pkg/slack.Version=1.2.3"
+var Version = "dev"
+
+var (
+	userAgent atomic.Pointer[string]
+	proxyFunc atomic.Pointer[func(*url.URL) (*url.URL, error)]
+)
+
+// outboundClient is shared by every request the bot makes, to Slack or
+// to its secret sources
+var outboundClient = &http.Client{
+	Timeout:   responseTimeout,
+	Transport: userAgentTransport{newOutboundTransport()},
+}
+
+// outboundDialer opens socket mode connections through the same proxy
+var outboundDialer = &websocket.Dialer{
+	Proxy:            outboundProxy,
+	HandshakeTimeout: websocket.DefaultDialer.HandshakeTimeout,
+}
+
+func newOutboundTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = outboundProxy
+
+	return transport
+}
+
+// SetProxy routes outbound requests through the HTTP proxy at the given
+// URL, except for the hosts excluded by the NO_PROXY environment
+// variable, such as internal secret stores, and localhost. An empty URL
+// restores the default of honouring the HTTP_PROXY, HTTPS_PROXY, and
+// NO_PROXY environment variables.
+func SetProxy(rawURL string) error {
+	if len(rawURL) == 0 {
+		proxyFunc.Store(nil)
+		return nil
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid proxy url: %w", err)
+	}
+	if len(parsed.Scheme) == 0 || len(parsed.Host) == 0 {
+		return fmt.Errorf("invalid proxy url %q: a scheme and host are required", rawURL)
+	}
+	config := httpproxy.FromEnvironment()
+	config.HTTPProxy = parsed.String()
+	config.HTTPSProxy = parsed.String()
+	proxy := config.ProxyFunc()
+	proxyFunc.Store(&proxy)
+
+	return nil
+}
+
+// outboundProxy picks the proxy for a request, preferring the one set
+// with SetProxy over the environment
+func outboundProxy(request *http.Request) (*url.URL, error) {
+	if proxy := proxyFunc.Load(); proxy != nil {
+		return (*proxy)(request.URL)
+	}
+
+	return http.ProxyFromEnvironment(request)
+}
+
+// DefaultUserAgent is the User-Agent used when none has been set
+func DefaultUserAgent() string {
+	return "pauwels-labs-slack-bot/" + Version
+}
+
+// SetUserAgent sets the User-Agent header sent with every outbound
+// request, so that the bot can be identified in Slack's and egress
+// proxies' logs. An empty value restores the default.
+func SetUserAgent(value string) {
+	if len(value) == 0 {
+		userAgent.Store(nil)
+		return
+	}
+	userAgent.Store(&value)
+}
+
+// UserAgent returns the User-Agent header sent with outbound requests
+func UserAgent() string {
+	if value := userAgent.Load(); value != nil {
+		return *value
+	}
+
+	return DefaultUserAgent()
+}
+
+// userAgentTransport sets the User-Agent header on every request
+type userAgentTransport struct {
+	base http.RoundTripper
+}
+
+func (t userAgentTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	request = request.Clone(request.Context())
+	request.Header.Set("user-agent", UserAgent())
+
+	return t.base.RoundTrip(request)
+}
@@ -0,0 +1,94 @@
+package slack
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRespondSetsUserAgent(t *testing.T) {
+	userAgents := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents <- r.Header.Get("user-agent")
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() {
+		SetUserAgent("")
+	})
+
+	err := Respond(server.URL, &SlackResponse{Text: "hi"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if userAgent := <-userAgents; userAgent != "pauwels-labs-slack-bot/dev" {
+		t.Errorf("expected the default user agent, got %q", userAgent)
+	}
+
+	SetUserAgent("custom-bot/1.0")
+	err = Respond(server.URL, &SlackResponse{Text: "hi"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if userAgent := <-userAgents; userAgent != "custom-bot/1.0" {
+		t.Errorf("expected the configured user agent, got %q", userAgent)
+	}
+}
+
+func TestRespondRoutesThroughProxy(t *testing.T) {
+	proxied := make(chan string, 1)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied <- r.URL.String()
+	}))
+	t.Cleanup(proxy.Close)
+	t.Cleanup(func() {
+		SetProxy("")
+	})
+
+	err := SetProxy(proxy.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = Respond("http://hooks.slack.invalid/commands/T1/1/abc", &SlackResponse{Text: "hi"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if target := <-proxied; target != "http://hooks.slack.invalid/commands/T1/1/abc" {
+		t.Errorf("expected the proxy to receive the response, got a request for %q", target)
+	}
+}
+
+func TestSetProxyRejectsInvalidURLs(t *testing.T) {
+	for _, rawURL := range []string{"proxy.internal:3128", "://", "http://"} {
+		if SetProxy(rawURL) == nil {
+			t.Errorf("expected %q to be rejected", rawURL)
+		}
+	}
+	if proxyFunc.Load() != nil {
+		t.Error("expected invalid proxies to leave the proxy unset")
+	}
+}
+
+func TestSetProxyHonoursNoProxy(t *testing.T) {
+	t.Setenv("NO_PROXY", ".internal")
+	t.Cleanup(func() {
+		SetProxy("")
+	})
+
+	err := SetProxy("http://proxy.example.com:3128")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for target, expected := range map[string]string{
+		"https://slack.com/api/chat.postMessage":       "http://proxy.example.com:3128",
+		"https://vault.internal:8200/v1/secret/data/a": "",
+	} {
+		request, _ := http.NewRequest("POST", target, nil)
+		proxy, err := outboundProxy(request)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if (proxy == nil && len(expected) > 0) || (proxy != nil && proxy.String() != expected) {
+			t.Errorf("expected %s to be sent through %q, got %v", target, expected, proxy)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package slack
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// ProgressReporter lets a handler post intermediate updates while it is
+// still working. Each update replaces the previously posted message.
+type ProgressReporter interface {
+	Update(text string)
+}
+
+type progressReporterContextKey struct{}
+
+type responseURLProgressReporter struct {
+	logger      *zap.Logger
+	responder   Responder
+	responseURL string
+}
+
+func newResponseURLProgressReporter(logger *zap.Logger, responder Responder, responseURL string) ProgressReporter {
+	return responseURLProgressReporter{
+		logger,
+		responder,
+		responseURL,
+	}
+}
+
+func (p responseURLProgressReporter) Update(text string) {
+	err := p.responder.Deliver(context.Background(), p.responseURL, &SlackResponse{
+		ResponseType:    "ephemeral",
+		Text:            text,
+		ReplaceOriginal: true,
+	})
+	if err != nil {
+		p.logger.Error("could not send progress update", zap.Error(err))
+	}
+}
+
+type noopProgressReporter struct{}
+
+func (p noopProgressReporter) Update(text string) {}
+
+func withProgressReporter(ctx context.Context, reporter ProgressReporter) context.Context {
+	return context.WithValue(ctx, progressReporterContextKey{}, reporter)
+}
+
+// ProgressReporterFromContext returns the ProgressReporter for the command
+// being handled, or a reporter that discards updates if there is none
+func ProgressReporterFromContext(ctx context.Context) ProgressReporter {
+	reporter, ok := ctx.Value(progressReporterContextKey{}).(ProgressReporter)
+	if !ok {
+		return noopProgressReporter{}
+	}
+
+	return reporter
+}
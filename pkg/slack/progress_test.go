@@ -0,0 +1,74 @@
+package slack
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+type multiStepHandler struct{}
+
+func (h multiStepHandler) Handle(arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	return h.HandleContext(context.Background(), arguments, request)
+}
+
+func (h multiStepHandler) HandleContext(ctx context.Context, arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	progress := ProgressReporterFromContext(ctx)
+	progress.Update("step 1 complete")
+	progress.Update("step 2 complete")
+
+	return &SlackResponse{
+		ResponseType:    "in_channel",
+		Text:            "all checks passed",
+		ReplaceOriginal: true,
+	}, nil
+}
+
+func (h multiStepHandler) Deferred() bool {
+	return true
+}
+
+func (h multiStepHandler) CommandName() string {
+	return "check"
+}
+
+func (h multiStepHandler) CommandArguments() string {
+	return ""
+}
+
+func (h multiStepHandler) CommandDescription() string {
+	return ""
+}
+
+func TestProgressUpdatesAreSentInOrder(t *testing.T) {
+	server, responses := newResponseServer(t)
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{multiStepHandler{}})
+
+	r := newSignedRequest("abc", url.Values{
+		"text":         {"check"},
+		"response_url": {server.URL},
+	})
+	w := httptest.NewRecorder()
+	handler(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected deferred command to be acknowledged, got status %d", w.Code)
+	}
+
+	for _, expected := range []string{"step 1 complete", "step 2 complete", "all checks passed"} {
+		response := receiveResponse(t, responses)
+		if response.Text != expected {
+			t.Errorf("expected %q, got %q", expected, response.Text)
+		}
+		if !response.ReplaceOriginal {
+			t.Errorf("expected %q to replace the original message", response.Text)
+		}
+	}
+}
+
+func TestProgressReporterFromEmptyContext(t *testing.T) {
+	ProgressReporterFromContext(context.Background()).Update("discarded")
+}
@@ -0,0 +1,57 @@
+package slack
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// ReadinessGate holds back requests until the bot is ready to process
+// them, such as once its config has been loaded for the first time. It
+// also tracks features that are degraded because something they depend
+// on, such as the Web API, is unavailable.
+type ReadinessGate struct {
+	ready    atomic.Bool
+	lock     sync.Mutex
+	degraded map[string]string
+}
+
+func NewReadinessGate() *ReadinessGate {
+	return &ReadinessGate{degraded: map[string]string{}}
+}
+
+func (g *ReadinessGate) MarkReady() {
+	g.ready.Store(true)
+}
+
+func (g *ReadinessGate) Ready() bool {
+	return g.ready.Load()
+}
+
+// MarkDegraded records that feature is unavailable for the given reason,
+// the bot keeps serving everything else
+func (g *ReadinessGate) MarkDegraded(feature string, reason string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.degraded[feature] = reason
+	degradedFeatures.WithLabelValues(feature).Set(1)
+}
+
+// MarkRecovered records that feature is available again
+func (g *ReadinessGate) MarkRecovered(feature string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	delete(g.degraded, feature)
+	degradedFeatures.WithLabelValues(feature).Set(0)
+}
+
+// Degraded returns the reason each degraded feature is unavailable
+func (g *ReadinessGate) Degraded() map[string]string {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	degraded := make(map[string]string, len(g.degraded))
+	for feature, reason := range g.degraded {
+		degraded[feature] = reason
+	}
+
+	return degraded
+}
@@ -0,0 +1,30 @@
+package slack
+
+import (
+	"context"
+	"time"
+)
+
+// Responder delivers a response to the target Slack gave for it, such as
+// a slash command's response_url. Replacing the default HTTPResponder
+// with WithResponder lets responses be captured in tests or sent over
+// another transport.
+type Responder interface {
+	Deliver(ctx context.Context, target string, response *SlackResponse) error
+}
+
+// HTTPResponder posts responses to response_urls, going through the
+// response circuit breaker and recording delivery metrics
+type HTTPResponder struct{}
+
+func (HTTPResponder) Deliver(ctx context.Context, target string, response *SlackResponse) error {
+	// Record the outcome and latency of every delivery
+	start := time.Now()
+	err := responseBreaker.Do(func() error {
+		return deliver(ctx, target, response)
+	})
+	responseDeliveryDuration.Observe(time.Since(start).Seconds())
+	responseDeliveries.WithLabelValues(deliveryOutcome(err)).Inc()
+
+	return err
+}
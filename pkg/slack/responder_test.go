@@ -0,0 +1,48 @@
+package slack
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type deliveredResponse struct {
+	target   string
+	response *SlackResponse
+}
+
+type fakeResponder struct {
+	deliveries chan deliveredResponse
+}
+
+func (f fakeResponder) Deliver(ctx context.Context, target string, response *SlackResponse) error {
+	f.deliveries <- deliveredResponse{target, response}
+	return nil
+}
+
+func TestHandlerUsesInjectedResponder(t *testing.T) {
+	responder := fakeResponder{make(chan deliveredResponse, 1)}
+	var arguments []string
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}}, WithResponder(responder))
+
+	handler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+		"text":         {"echo hi"},
+		"response_url": {"https://hooks.slack.com/commands/T1/1/abc"},
+	}))
+
+	select {
+	case delivered := <-responder.deliveries:
+		if delivered.target != "https://hooks.slack.com/commands/T1/1/abc" {
+			t.Errorf("expected delivery to the response_url, got %q", delivered.target)
+		}
+		if delivered.response.Text != "echo" || delivered.response.ResponseType != "in_channel" {
+			t.Errorf("unexpected response %+v", delivered.response)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no response was delivered to the responder")
+	}
+}
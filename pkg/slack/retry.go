@@ -0,0 +1,104 @@
+package slack
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Canonical names of the headers Slack adds when retrying a delivery
+const (
+	RetryNumHeaderName    = "X-Slack-Retry-Num"
+	RetryReasonHeaderName = "X-Slack-Retry-Reason"
+)
+
+// How long delivered requests are remembered to drop their retries
+const defaultRetryWindow = 10 * time.Minute
+
+// Retry describes Slack's retry of a request it considers undelivered
+type Retry struct {
+	// Num is the number of the retry, starting from 1
+	Num int
+	// Reason is why Slack retried, such as `http_timeout`
+	Reason string
+}
+
+type retryContextKey struct{}
+
+// RetryFromContext returns the retry the handled request is, if it is one
+func RetryFromContext(ctx context.Context) (Retry, bool) {
+	retry, ok := ctx.Value(retryContextKey{}).(Retry)
+	return retry, ok
+}
+
+func withRetry(ctx context.Context, retry *Retry) context.Context {
+	if retry == nil {
+		return ctx
+	}
+
+	return context.WithValue(ctx, retryContextKey{}, *retry)
+}
+
+// readRetry reads Slack's retry headers, returning nil when the request
+// isn't a retry
+func readRetry(header http.Header) *Retry {
+	num, err := strconv.Atoi(headerValue(header, RetryNumHeaderName))
+	if err != nil {
+		return nil
+	}
+
+	return &Retry{
+		Num:    num,
+		Reason: headerValue(header, RetryReasonHeaderName),
+	}
+}
+
+// RetryDeduplicator remembers the IDs of delivered requests for a window
+// so that Slack's retries of them can be dropped
+type RetryDeduplicator struct {
+	window time.Duration
+	lock   sync.Mutex
+	seen   map[string]time.Time
+}
+
+// NewRetryDeduplicator remembers delivered requests for window, ten
+// minutes when window isn't positive
+func NewRetryDeduplicator(window time.Duration) *RetryDeduplicator {
+	if window <= 0 {
+		window = defaultRetryWindow
+	}
+
+	return &RetryDeduplicator{
+		window: window,
+		seen:   map[string]time.Time{},
+	}
+}
+
+// duplicate records the delivery of id, reporting whether it is a retry
+// of a delivery already seen within the window
+func (d *RetryDeduplicator) duplicate(id string, retry *Retry) bool {
+	if d == nil || len(id) == 0 {
+		return false
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	// Forget deliveries that are too old to be retried
+	now := time.Now()
+	for seenID, seenAt := range d.seen {
+		if now.Sub(seenAt) > d.window {
+			delete(d.seen, seenID)
+		}
+	}
+
+	_, seen := d.seen[id]
+	if seen && retry != nil {
+		return true
+	}
+	d.seen[id] = now
+
+	return false
+}
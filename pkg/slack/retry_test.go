@@ -0,0 +1,105 @@
+package slack
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type retryRecordingHandler struct {
+	recordingHandler
+	retries chan *Retry
+}
+
+func (h retryRecordingHandler) HandleContext(ctx context.Context, arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	retry, ok := RetryFromContext(ctx)
+	if ok {
+		h.retries <- &retry
+	} else {
+		h.retries <- nil
+	}
+
+	return &SlackResponse{Text: h.name}, nil
+}
+
+func TestRetriesOfDeliveredCommandsAreDropped(t *testing.T) {
+	server, responses := newResponseServer(t)
+	handler := retryRecordingHandler{recordingHandler{name: "echo"}, make(chan *Retry, 4)}
+	buildHandler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{handler})
+	newRequest := func(triggerID string, retryNum string) {
+		r := newSignedRequest("abc", url.Values{
+			"text":         {"echo"},
+			"trigger_id":   {triggerID},
+			"response_url": {server.URL},
+		})
+		if len(retryNum) > 0 {
+			r.Header.Set(RetryNumHeaderName, retryNum)
+			r.Header.Set(RetryReasonHeaderName, "http_timeout")
+		}
+		buildHandler(httptest.NewRecorder(), r)
+	}
+
+	// The original delivery is handled, its retry is dropped
+	newRequest("T1", "")
+	newRequest("T1", "1")
+	if retry := <-handler.retries; retry != nil {
+		t.Errorf("expected the original delivery not to be a retry, got %+v", retry)
+	}
+	receiveResponse(t, responses)
+	select {
+	case retry := <-handler.retries:
+		t.Errorf("expected the duplicate retry to be dropped, it was handled as %+v", retry)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// A retry of a delivery that never arrived is handled and tells the
+	// handler it is a retry
+	newRequest("T2", "2")
+	retry := <-handler.retries
+	if retry == nil || retry.Num != 2 || retry.Reason != "http_timeout" {
+		t.Errorf("expected retry 2 for http_timeout, got %+v", retry)
+	}
+	receiveResponse(t, responses)
+}
+
+func TestRetryDeduplicatorIsSharedBetweenBots(t *testing.T) {
+	server, responses := newResponseServer(t)
+	handler := retryRecordingHandler{recordingHandler{name: "echo"}, make(chan *Retry, 2)}
+	deduplicator := NewRetryDeduplicator(0)
+	for _, retryNum := range []string{"", "1"} {
+		r := newSignedRequest("abc", url.Values{
+			"text":         {"echo"},
+			"trigger_id":   {"T1"},
+			"response_url": {server.URL},
+		})
+		if len(retryNum) > 0 {
+			r.Header.Set(RetryNumHeaderName, retryNum)
+		}
+		buildHandler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{handler}, WithRetryDeduplicator(deduplicator))
+		buildHandler(httptest.NewRecorder(), r)
+	}
+
+	// The retry reaching a bot built after the original delivery is
+	// still dropped
+	<-handler.retries
+	receiveResponse(t, responses)
+	select {
+	case retry := <-handler.retries:
+		t.Errorf("expected the retry to be dropped by the new bot, it was handled as %+v", retry)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestRetryDeduplicatorForgetsOldDeliveries(t *testing.T) {
+	deduplicator := NewRetryDeduplicator(time.Millisecond)
+	deduplicator.duplicate("T1", nil)
+	time.Sleep(5 * time.Millisecond)
+
+	if deduplicator.duplicate("T1", &Retry{Num: 1}) {
+		t.Errorf("expected a retry outside the window not to be dropped")
+	}
+}
@@ -0,0 +1,88 @@
+package slack
+
+import (
+	"context"
+	"regexp"
+)
+
+// Broadcast mentions, either as Slack sends them, such as <!here> or
+// <!channel|channel>, or as typed when Slack doesn't escape the command
+var (
+	escapedBroadcastPattern = regexp.MustCompile(`<!(channel|here|everyone)(\|[^>]*)?>`)
+	typedBroadcastPattern   = regexp.MustCompile(`@(channel|here|everyone)\b`)
+)
+
+// Slack escapes &, < and > in command text and encodes user mentions,
+// channels and links as <@U123|name>, <#C123|name> and <https://...>,
+// which are kept, while any other control character is escaped
+var controlCharacterPattern = regexp.MustCompile(`&(amp|lt|gt);|<([@#][^<>]*|[a-zA-Z][a-zA-Z0-9+.-]*:[^<>]*)>|[&<>]`)
+
+// SanitizeOptions chooses what a SanitizingHandler neutralizes in user
+// input before the handler sees it
+type SanitizeOptions struct {
+	// Broadcasts turns @channel, @here and @everyone into text that
+	// doesn't notify anyone when it is posted back
+	Broadcasts bool
+	// ControlCharacters escapes the characters mrkdwn uses for control
+	// sequences that Slack didn't already escape, along with special
+	// mentions such as <!subteam^ID>, so that input is shown as typed.
+	// User mentions, channels and links encoded by Slack are kept.
+	ControlCharacters bool
+}
+
+// DefaultSanitizeOptions neutralizes everything a SanitizingHandler can
+var DefaultSanitizeOptions = SanitizeOptions{
+	Broadcasts:        true,
+	ControlCharacters: true,
+}
+
+// SanitizingHandler wraps a handler, sanitizing its arguments and the
+// command text before they reach it, so that handlers posting user input
+// back don't each have to escape it. Only the handler's Handle and
+// HandleContext methods are wrapped, so handlers relying on other
+// optional interfaces, such as deferred handlers, should sanitize their
+// input with SanitizeArgument instead.
+type SanitizingHandler struct {
+	SlackSlashCommandHandler
+	options SanitizeOptions
+}
+
+// NewSanitizingHandler wraps handler, sanitizing its input as set by
+// options
+func NewSanitizingHandler(handler SlackSlashCommandHandler, options SanitizeOptions) SlackSlashCommandHandler {
+	return SanitizingHandler{handler, options}
+}
+
+func (h SanitizingHandler) Handle(arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	return h.HandleContext(context.Background(), arguments, request)
+}
+
+func (h SanitizingHandler) HandleContext(ctx context.Context, arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	sanitized := make([]string, len(arguments))
+	for i, argument := range arguments {
+		sanitized[i] = SanitizeArgument(argument, h.options)
+	}
+	request.Text = SanitizeArgument(request.Text, h.options)
+
+	return invoke(ctx, h.SlackSlashCommandHandler, sanitized, request)
+}
+
+// SanitizeArgument neutralizes user input as set by options
+func SanitizeArgument(argument string, options SanitizeOptions) string {
+	// Broadcasts are rewritten with a zero-width space after the @, which
+	// keeps them readable without Slack recognizing them
+	if options.Broadcasts {
+		argument = escapedBroadcastPattern.ReplaceAllString(argument, "@$1")
+		argument = typedBroadcastPattern.ReplaceAllString(argument, "@\u200b$1")
+	}
+	if options.ControlCharacters {
+		argument = controlCharacterPattern.ReplaceAllStringFunc(argument, func(match string) string {
+			if len(match) == 1 {
+				return EscapeMarkdown(match)
+			}
+			return match
+		})
+	}
+
+	return argument
+}
@@ -0,0 +1,28 @@
+package slack
+
+import "testing"
+
+func TestSanitizeArgument(t *testing.T) {
+	tests := []struct {
+		argument string
+		options  SanitizeOptions
+		expected string
+	}{
+		{"<!here|here>", SanitizeOptions{Broadcasts: true}, "@\u200bhere"},
+		{"@everyone", SanitizeOptions{Broadcasts: true}, "@\u200beveryone"},
+		{"<!channel>", SanitizeOptions{ControlCharacters: true}, "&lt;!channel&gt;"},
+		{"<!subteam^S123>", SanitizeOptions{ControlCharacters: true}, "&lt;!subteam^S123&gt;"},
+		{"<b>R&D</b>", DefaultSanitizeOptions, "&lt;b&gt;R&amp;D&lt;/b&gt;"},
+		// Slack escapes command text and encodes mentions and links itself
+		{"R&amp;D &lt;b&gt;", DefaultSanitizeOptions, "R&amp;D &lt;b&gt;"},
+		{"<@U123|bob> in <#C123|general>", DefaultSanitizeOptions, "<@U123|bob> in <#C123|general>"},
+		{"<https://example.com|docs>", DefaultSanitizeOptions, "<https://example.com|docs>"},
+		{"<!channel>", SanitizeOptions{}, "<!channel>"},
+	}
+
+	for _, test := range tests {
+		if sanitized := SanitizeArgument(test.argument, test.options); sanitized != test.expected {
+			t.Errorf("expected %q to be sanitized to %q, got %q", test.argument, test.expected, sanitized)
+		}
+	}
+}
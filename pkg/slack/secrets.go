@@ -0,0 +1,115 @@
+package slack
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrNoSigningKey is returned by secret sources that weren't told where
+// to find the signing key, since verifying requests with an empty key
+// would accept forged ones
+var ErrNoSigningKey = errors.New("no signing key location is configured")
+
+// SecretSource provides the secrets the bot authenticates with, which
+// may change between calls as they are rotated
+type SecretSource interface {
+	SigningKey() (string, error)
+	BotToken() (string, error)
+}
+
+// StaticSecretSource always provides the same secrets, such as those
+// read from the bot's config
+type StaticSecretSource struct {
+	signingKey string
+	botToken   string
+}
+
+func NewStaticSecretSource(signingKey string, botToken string) StaticSecretSource {
+	return StaticSecretSource{signingKey, botToken}
+}
+
+func (s StaticSecretSource) SigningKey() (string, error) {
+	return s.signingKey, nil
+}
+
+func (s StaticSecretSource) BotToken() (string, error) {
+	return s.botToken, nil
+}
+
+// FileSecretSource reads each secret from its own file on every call,
+// such as a mounted Kubernetes secret, so that rotated secrets are picked
+// up without a restart. Surrounding whitespace is ignored. A bot token
+// without a path is empty, while a signing key without one is an
+// ErrNoSigningKey.
+type FileSecretSource struct {
+	signingKeyPath string
+	botTokenPath   string
+}
+
+func NewFileSecretSource(signingKeyPath string, botTokenPath string) FileSecretSource {
+	return FileSecretSource{signingKeyPath, botTokenPath}
+}
+
+func (s FileSecretSource) SigningKey() (string, error) {
+	if len(s.signingKeyPath) == 0 {
+		return "", ErrNoSigningKey
+	}
+
+	return readSecretFile(s.signingKeyPath)
+}
+
+func (s FileSecretSource) BotToken() (string, error) {
+	return readSecretFile(s.botTokenPath)
+}
+
+func readSecretFile(path string) (string, error) {
+	if len(path) == 0 {
+		return "", nil
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("could not read secret file: %w", err)
+	}
+
+	return strings.TrimSpace(string(contents)), nil
+}
+
+// EnvSecretSource reads each secret from the named environment variable
+// on every call. A bot token without a variable name is empty, while a
+// signing key without one is an ErrNoSigningKey.
+type EnvSecretSource struct {
+	signingKeyVar string
+	botTokenVar   string
+}
+
+func NewEnvSecretSource(signingKeyVar string, botTokenVar string) EnvSecretSource {
+	return EnvSecretSource{signingKeyVar, botTokenVar}
+}
+
+func (s EnvSecretSource) SigningKey() (string, error) {
+	if len(s.signingKeyVar) == 0 {
+		return "", ErrNoSigningKey
+	}
+
+	return readSecretEnv(s.signingKeyVar)
+}
+
+func (s EnvSecretSource) BotToken() (string, error) {
+	return readSecretEnv(s.botTokenVar)
+}
+
+func readSecretEnv(name string) (string, error) {
+	if len(name) == 0 {
+		return "", nil
+	}
+
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+
+	return value, nil
+}
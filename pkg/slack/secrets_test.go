@@ -0,0 +1,170 @@
+package slack
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// rotatingSecretSource provides a different signing key on every call
+type rotatingSecretSource struct {
+	keys []string
+	err  error
+}
+
+func (s *rotatingSecretSource) SigningKey() (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+	key := s.keys[0]
+	s.keys = s.keys[1:]
+
+	return key, nil
+}
+
+func (s *rotatingSecretSource) BotToken() (string, error) {
+	return "", s.err
+}
+
+func TestStaticSecretSource(t *testing.T) {
+	source := NewStaticSecretSource("key", "xoxb-test")
+
+	if key, err := source.SigningKey(); err != nil || key != "key" {
+		t.Errorf("expected the signing key, got %q, %v", key, err)
+	}
+	if token, err := source.BotToken(); err != nil || token != "xoxb-test" {
+		t.Errorf("expected the bot token, got %q, %v", token, err)
+	}
+}
+
+func TestFileSecretSource(t *testing.T) {
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "signingkey")
+	os.WriteFile(keyPath, []byte("first\n"), 0600)
+	source := NewFileSecretSource(keyPath, "")
+
+	if key, err := source.SigningKey(); err != nil || key != "first" {
+		t.Errorf("expected the trimmed signing key, got %q, %v", key, err)
+	}
+	if token, err := source.BotToken(); err != nil || token != "" {
+		t.Errorf("expected no bot token without a path, got %q, %v", token, err)
+	}
+
+	// The file is read again on every call
+	os.WriteFile(keyPath, []byte("second"), 0600)
+	if key, _ := source.SigningKey(); key != "second" {
+		t.Errorf("expected the rotated signing key, got %q", key)
+	}
+
+	missing := NewFileSecretSource(filepath.Join(dir, "missing"), "")
+	if _, err := missing.SigningKey(); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestEnvSecretSource(t *testing.T) {
+	t.Setenv("TEST_SIGNING_KEY", "first")
+	source := NewEnvSecretSource("TEST_SIGNING_KEY", "TEST_BOT_TOKEN")
+
+	if key, err := source.SigningKey(); err != nil || key != "first" {
+		t.Errorf("expected the signing key, got %q, %v", key, err)
+	}
+	if _, err := source.BotToken(); err == nil {
+		t.Error("expected an error for an unset variable")
+	}
+
+	t.Setenv("TEST_SIGNING_KEY", "second")
+	if key, _ := source.SigningKey(); key != "second" {
+		t.Errorf("expected the rotated signing key, got %q", key)
+	}
+}
+
+func TestHandlerUsesRotatedSigningKey(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	source := &rotatingSecretSource{keys: []string{"first", "second", "second"}}
+	handler := BuildHandler(zap.NewNop(), source, []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}})
+
+	// Each request is verified against the key provided at the time
+	for i, test := range []struct {
+		signingKey string
+		handled    bool
+	}{
+		{"first", true},
+		{"first", false},
+		{"second", true},
+	} {
+		handler(httptest.NewRecorder(), newSignedRequest(test.signingKey, url.Values{
+			"text":         {"echo hi"},
+			"response_url": {server.URL},
+		}))
+		select {
+		case <-responses:
+			if !test.handled {
+				t.Errorf("request %d signed with the rotated out key %q was handled", i, test.signingKey)
+			}
+		case <-time.After(100 * time.Millisecond):
+			if test.handled {
+				t.Errorf("request %d signed with the current key %q was not handled", i, test.signingKey)
+			}
+		}
+	}
+}
+
+func TestHandlerFailsWithoutSigningKey(t *testing.T) {
+	source := &rotatingSecretSource{err: errors.New("unavailable")}
+	handler := BuildHandler(zap.NewNop(), source, []SlackSlashCommandHandler{})
+
+	w := httptest.NewRecorder()
+	handler(w, newSignedRequest("first", url.Values{"command": []string{"/unknown"}}))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected a 500 when the signing key can't be read, got %d", w.Code)
+	}
+}
+
+func TestSecretSourcesWithoutSigningKeyLocation(t *testing.T) {
+	for name, source := range map[string]SecretSource{
+		"file": NewFileSecretSource("", ""),
+		"env":  NewEnvSecretSource("", ""),
+	} {
+		if _, err := source.SigningKey(); !errors.Is(err, ErrNoSigningKey) {
+			t.Errorf("expected the %s source to report the missing signing key location, got %v", name, err)
+		}
+		if token, err := source.BotToken(); err != nil || token != "" {
+			t.Errorf("expected no bot token from the %s source without a location, got %q, %v", name, token, err)
+		}
+	}
+}
+
+func TestHandlerRefusesRequestsWithoutSigningKey(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	for name, source := range map[string]SecretSource{
+		"empty key":        NewStaticSecretSource("", ""),
+		"missing location": NewEnvSecretSource("", ""),
+	} {
+		handler := BuildHandler(zap.NewNop(), source, []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}})
+
+		// A request signed with an empty key must not be accepted
+		w := httptest.NewRecorder()
+		handler(w, newSignedRequest("", url.Values{
+			"text":         {"echo hi"},
+			"response_url": {server.URL},
+		}))
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("expected a 500 with %s, got %d", name, w.Code)
+		}
+		select {
+		case <-responses:
+			t.Errorf("a request forged with %s was handled", name)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package slack
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Slack generates signing secrets of this length
+const signingKeyLength = 32
+
+// WarnOnSuspiciousSigningKey logs a warning for each reason the signing
+// key looks misconfigured. A wrong signing key silently fails every
+// request, so this catches common mistakes such as pasting a bot token
+// into the wrong setting, without refusing to start.
+func WarnOnSuspiciousSigningKey(logger *zap.Logger, signingKey string) {
+	for _, warning := range signingKeyWarnings(signingKey) {
+		logger.Warn(warning, zap.Int("signingKeyLength", len(signingKey)))
+	}
+}
+
+func signingKeyWarnings(signingKey string) []string {
+	if len(signingKey) == 0 {
+		return []string{"slack signing key is empty, every request will be rejected"}
+	}
+
+	warnings := []string{}
+	if strings.TrimSpace(signingKey) != signingKey {
+		warnings = append(warnings, "slack signing key has leading or trailing whitespace")
+	}
+	for _, prefix := range []string{"xoxb-", "xoxp-", "xoxa-", "xapp-"} {
+		if strings.HasPrefix(signingKey, prefix) {
+			warnings = append(warnings, "slack signing key looks like a token, use the signing secret from the app's basic information instead")
+			break
+		}
+	}
+	if len(strings.TrimSpace(signingKey)) < signingKeyLength {
+		warnings = append(warnings, "slack signing key is shorter than the signing secrets generated by slack")
+	}
+
+	return warnings
+}
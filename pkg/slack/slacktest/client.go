@@ -0,0 +1,143 @@
+// Package slacktest provides test doubles for code calling Slack
+package slacktest
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pauwels-labs/slack-bot/pkg/slack"
+)
+
+// Call is a Web API call made to a FakeClient. Only the fields the
+// method takes are set.
+type Call struct {
+	// Method is the name of the client's method, such as PostMessage
+	Method    string
+	Channel   string
+	Text      string
+	Blocks    []slack.Block
+	Timestamp string
+	PostAt    time.Time
+	Emoji     string
+	Filename  string
+	Content   []byte
+}
+
+// FakeClient implements slack.SlackAPI, recording every call instead of
+// calling Slack, so that handlers can be tested without a server. Methods
+// succeed unless Errors holds an error under their name.
+type FakeClient struct {
+	// Errors is returned by the method of the same name, such as
+	// PostMessage, when set
+	Errors map[string]error
+	// Members are the channels IsMember reports the bot is in, every
+	// channel when nil
+	Members map[string]bool
+
+	lock  sync.Mutex
+	calls []Call
+	ids   int
+}
+
+var _ slack.SlackAPI = (*FakeClient)(nil)
+
+func NewFakeClient() *FakeClient {
+	return &FakeClient{Errors: map[string]error{}}
+}
+
+// Calls returns the calls made so far, in order
+func (c *FakeClient) Calls() []Call {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return append([]Call{}, c.calls...)
+}
+
+// CallsTo returns the calls made so far to the given method, in order
+func (c *FakeClient) CallsTo(method string) []Call {
+	calls := []Call{}
+	for _, call := range c.Calls() {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+
+	return calls
+}
+
+// record adds the call, returning the error configured for its method and
+// a new ID for whatever the call creates
+func (c *FakeClient) record(call Call) (int, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.calls = append(c.calls, call)
+	c.ids++
+
+	return c.ids, c.Errors[call.Method]
+}
+
+func (c *FakeClient) ScheduleMessage(channel string, postAt time.Time, text string) (string, error) {
+	id, err := c.record(Call{Method: "ScheduleMessage", Channel: channel, PostAt: postAt, Text: text})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Q%d", id), nil
+}
+
+func (c *FakeClient) PostMessage(channel string, text string) (string, error) {
+	id, err := c.record(Call{Method: "PostMessage", Channel: channel, Text: text})
+	if err != nil {
+		return "", err
+	}
+
+	return timestamp(id), nil
+}
+
+func (c *FakeClient) PostBlocks(channel string, text string, blocks []slack.Block) (string, error) {
+	id, err := c.record(Call{Method: "PostBlocks", Channel: channel, Text: text, Blocks: blocks})
+	if err != nil {
+		return "", err
+	}
+
+	return timestamp(id), nil
+}
+
+func (c *FakeClient) DeleteMessage(channel string, ts string) error {
+	_, err := c.record(Call{Method: "DeleteMessage", Channel: channel, Timestamp: ts})
+	return err
+}
+
+func (c *FakeClient) AddReaction(channel string, timestamp string, emoji string) error {
+	_, err := c.record(Call{Method: "AddReaction", Channel: channel, Timestamp: timestamp, Emoji: emoji})
+	return err
+}
+
+func (c *FakeClient) IsMember(channel string) (bool, error) {
+	_, err := c.record(Call{Method: "IsMember", Channel: channel})
+	if err != nil {
+		return false, err
+	}
+
+	return c.Members == nil || c.Members[channel], nil
+}
+
+func (c *FakeClient) AuthTest() error {
+	_, err := c.record(Call{Method: "AuthTest"})
+	return err
+}
+
+func (c *FakeClient) UploadFile(channel string, filename string, content []byte) (string, error) {
+	id, err := c.record(Call{Method: "UploadFile", Channel: channel, Filename: filename, Content: content})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("F%d", id), nil
+}
+
+// timestamp builds a message timestamp in Slack's format
+func timestamp(id int) string {
+	return fmt.Sprintf("1700000000.%06d", id)
+}
@@ -0,0 +1,62 @@
+package slacktest
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFakeClientRecordsCalls(t *testing.T) {
+	client := NewFakeClient()
+	ts, err := client.PostMessage("C123", "hello")
+	if err != nil || ts == "" {
+		t.Fatalf("expected a timestamp, got %q and %v", ts, err)
+	}
+	client.AddReaction("C123", ts, "tada")
+	client.ScheduleMessage("C456", time.Unix(1700000000, 0), "later")
+
+	calls := client.Calls()
+	if len(calls) != 3 {
+		t.Fatalf("expected 3 calls, got %+v", calls)
+	}
+	if calls[0].Method != "PostMessage" || calls[0].Text != "hello" || calls[0].Channel != "C123" {
+		t.Errorf("unexpected first call %+v", calls[0])
+	}
+	if calls[1].Method != "AddReaction" || calls[1].Timestamp != ts || calls[1].Emoji != "tada" {
+		t.Errorf("unexpected second call %+v", calls[1])
+	}
+	if scheduled := client.CallsTo("ScheduleMessage"); len(scheduled) != 1 || scheduled[0].Channel != "C456" {
+		t.Errorf("unexpected scheduled messages %+v", scheduled)
+	}
+}
+
+func TestFakeClientReturnsConfiguredErrors(t *testing.T) {
+	client := NewFakeClient()
+	client.Errors["PostMessage"] = errors.New("channel_not_found")
+
+	_, err := client.PostMessage("C123", "hello")
+	if !errors.Is(err, client.Errors["PostMessage"]) {
+		t.Errorf("expected the configured error, got %v", err)
+	}
+	if len(client.CallsTo("PostMessage")) != 1 {
+		t.Errorf("expected the failed call to be recorded")
+	}
+	if _, err := client.UploadFile("C123", "a.txt", nil); err != nil {
+		t.Errorf("expected other methods to succeed, got %v", err)
+	}
+}
+
+func TestFakeClientMembers(t *testing.T) {
+	client := NewFakeClient()
+	if member, _ := client.IsMember("C123"); !member {
+		t.Errorf("expected the bot to be in every channel without Members")
+	}
+
+	client.Members = map[string]bool{"C456": true}
+	if member, _ := client.IsMember("C123"); member {
+		t.Errorf("expected the bot not to be in C123")
+	}
+	if member, _ := client.IsMember("C456"); !member {
+		t.Errorf("expected the bot to be in C456")
+	}
+}
@@ -0,0 +1,254 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"go.uber.org/zap"
+)
+
+const defaultSlackAPIURL = "https://slack.com/api/"
+
+// SocketModeServer receives slash commands over an outbound WebSocket
+// using Slack Socket Mode instead of exposing a public HTTP endpoint. It
+// dispatches them to the same handlers as SlackBot.
+type SocketModeServer struct {
+	appToken string
+	apiURL   string
+	handlers []SlackSlashCommandHandler
+	options  slackBotOptions
+}
+
+type socketModeEnvelope struct {
+	Type       string          `json:"type"`
+	EnvelopeID string          `json:"envelope_id,omitempty"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	Reason     string          `json:"reason,omitempty"`
+}
+
+type socketModeAck struct {
+	EnvelopeID string `json:"envelope_id"`
+}
+
+type connectionsOpenResponse struct {
+	OK    bool   `json:"ok"`
+	URL   string `json:"url"`
+	Error string `json:"error"`
+}
+
+// NewSocketModeServer creates a Socket Mode server authenticating with
+// the given app-level token, which must have the connections:write scope
+func NewSocketModeServer(appToken string, handlers []SlackSlashCommandHandler, options ...SlackBotOption) *SocketModeServer {
+	opts := newSlackBotOptions(options)
+	if opts.idempotency == nil {
+		opts.idempotency = NewIdempotencyCache()
+	}
+	if opts.conversations == nil {
+		opts.conversations = NewMemoryConversationStore(defaultConversationTTL)
+	}
+
+	return &SocketModeServer{
+		appToken: appToken,
+		apiURL:   defaultSlackAPIURL,
+		handlers: withHelpHandler(handlers, options),
+		options:  opts,
+	}
+}
+
+// Run connects to Slack and handles envelopes until the context is
+// cancelled. Slack periodically asks clients to disconnect, in which case
+// Run reconnects immediately. Failed connections are retried with
+// exponential backoff and jitter until the reconnect policy's retries are
+// exhausted.
+func (s *SocketModeServer) Run(ctx context.Context, logger *zap.Logger) error {
+	failures := 0
+	for {
+		connected, err := s.runSession(ctx, logger)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// Reconnect straight away when Slack asks us to
+		var disconnectErr *SocketModeDisconnectError
+		if errors.As(err, &disconnectErr) {
+			failures = 0
+			continue
+		}
+
+		// Otherwise back off, starting over if the session had connected
+		if connected {
+			failures = 0
+		}
+		failures++
+		if s.options.reconnectPolicy.MaxRetries > 0 && failures > s.options.reconnectPolicy.MaxRetries {
+			return err
+		}
+		delay := s.options.reconnectPolicy.backoff(failures)
+		logger.Warn("socket mode connection failed, reconnecting", zap.Error(err), zap.Int("attempt", failures), zap.Duration("delay", delay))
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// runSession handles a single socket mode connection, reporting whether
+// it was established before it ended
+func (s *SocketModeServer) runSession(ctx context.Context, logger *zap.Logger) (bool, error) {
+	// Fetch a WebSocket URL for this session
+	url, err := s.openConnection(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	// Connect, closing the connection when the context is cancelled to
+	// unblock the read loop
+	conn, _, err := outboundDialer.DialContext(ctx, url, http.Header{"User-Agent": {UserAgent()}})
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+	defer stop()
+
+	for {
+		var envelope socketModeEnvelope
+		err := conn.ReadJSON(&envelope)
+		if err != nil {
+			return true, err
+		}
+
+		// Acknowledge every envelope that asks for it before handling it
+		if len(envelope.EnvelopeID) > 0 {
+			err = conn.WriteJSON(socketModeAck{envelope.EnvelopeID})
+			if err != nil {
+				return true, err
+			}
+		}
+
+		switch envelope.Type {
+		case "hello":
+			logger.Info("connected to slack socket mode")
+		case "disconnect":
+			logger.Info("slack requested a socket mode disconnect", zap.String("reason", envelope.Reason))
+			return true, &SocketModeDisconnectError{envelope.Reason}
+		case "slash_commands":
+			s.handleSlashCommand(logger, envelope.Payload)
+		case "interactive":
+			handleInteraction(context.Background(), logger, s.options, s.handlers, string(envelope.Payload))
+		default:
+			logger.Info("ignoring unsupported socket mode envelope", zap.String("type", envelope.Type))
+		}
+	}
+}
+
+func (s *SocketModeServer) openConnection(ctx context.Context) (string, error) {
+	request, err := http.NewRequestWithContext(ctx, "POST", s.apiURL+"apps.connections.open", nil)
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("authorization", "Bearer "+s.appToken)
+
+	response, err := outboundClient.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	var body connectionsOpenResponse
+	err = json.NewDecoder(response.Body).Decode(&body)
+	if err != nil {
+		return "", err
+	}
+	if !body.OK {
+		return "", fmt.Errorf("apps.connections.open failed: %s", body.Error)
+	}
+
+	return body.URL, nil
+}
+
+func (s *SocketModeServer) handleSlashCommand(logger *zap.Logger, payload json.RawMessage) {
+	// Decode the payload, which carries the same fields as the HTTP form
+	undecodedPayload := map[string]interface{}{}
+	err := json.Unmarshal(payload, &undecodedPayload)
+	if err != nil {
+		logger.Error("unable to parse slash command payload", zap.Error(err))
+		return
+	}
+	var slashCommandBody SlackSlashCommandBody
+	err = mapstructure.Decode(undecodedPayload, &slashCommandBody)
+	if err != nil {
+		logger.Error("unable to decode slash command payload into struct", zap.Error(err))
+		respondUnparseable(logger, s.options.responder, slashCommandBody.ResponseURL)
+		return
+	}
+
+	// The envelope is already acknowledged, so the handler runs in the
+	// background and responds through the response_url
+	if reason := textLimitExceeded(s.options, slashCommandBody); len(reason) > 0 {
+		respondOverLimit(logger, s.options.responder, slashCommandBody.ResponseURL, reason)
+		return
+	}
+	handler, commandArguments := route(s.handlers, slashCommandBody, s.options)
+	if handler == nil {
+		return
+	}
+	if reason := argumentLimitExceeded(s.options, commandArguments); len(reason) > 0 {
+		respondOverLimit(logger, s.options.responder, slashCommandBody.ResponseURL, reason)
+		return
+	}
+	if promptForConfirmation(context.Background(), logger, s.options, handler, commandArguments, slashCommandBody) {
+		return
+	}
+	dispatchInBackground(withForm(context.Background(), stringFields(undecodedPayload)), logger, handler, commandArguments, slashCommandBody, s.options, nil)
+}
+
+// SocketModeDisconnectError is returned by Run when Slack asks the client
+// to disconnect, which it does periodically to rotate connections
+type SocketModeDisconnectError struct {
+	Reason string
+}
+
+func (e *SocketModeDisconnectError) Error() string {
+	return fmt.Sprintf("slack requested a disconnect: %s", e.Reason)
+}
+
+// ReconnectPolicy controls how the socket mode server retries failed
+// connections. The delay doubles after each consecutive failure, from
+// MinDelay up to MaxDelay, with up to half of it randomised to avoid many
+// clients reconnecting in lockstep. A MaxRetries of zero retries forever.
+type ReconnectPolicy struct {
+	MaxRetries int
+	MinDelay   time.Duration
+	MaxDelay   time.Duration
+}
+
+var DefaultReconnectPolicy = ReconnectPolicy{
+	MaxRetries: 10,
+	MinDelay:   time.Second,
+	MaxDelay:   time.Minute,
+}
+
+func (p ReconnectPolicy) backoff(attempt int) time.Duration {
+	delay := p.MinDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
@@ -0,0 +1,173 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+var testReconnectPolicy = ReconnectPolicy{
+	MaxRetries: 2,
+	MinDelay:   time.Millisecond,
+	MaxDelay:   10 * time.Millisecond,
+}
+
+// fakeSocketModeServer stands in for both Slack's Web API and its Socket
+// Mode WebSocket. Each connection is sent the next session's envelopes,
+// where an envelope of type `close` drops the connection abruptly, and
+// the envelope IDs acknowledged by the client are recorded.
+type fakeSocketModeServer struct {
+	*httptest.Server
+	connections atomic.Int32
+	acks        chan string
+}
+
+func newFakeSocketModeServer(t *testing.T, sessions ...[]socketModeEnvelope) *fakeSocketModeServer {
+	fake := &fakeSocketModeServer{acks: make(chan string, 16)}
+	upgrader := websocket.Upgrader{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/apps.connections.open", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("authorization") != "Bearer xapp-token" {
+			json.NewEncoder(w).Encode(connectionsOpenResponse{OK: false, Error: "invalid_auth"})
+			return
+		}
+		json.NewEncoder(w).Encode(connectionsOpenResponse{OK: true, URL: "ws" + strings.TrimPrefix(fake.URL, "http") + "/ws"})
+	})
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("could not upgrade connection: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		// Send this session's envelopes, then record acks until the client
+		// goes away
+		session := int(fake.connections.Add(1)) - 1
+		if session < len(sessions) {
+			for _, envelope := range sessions[session] {
+				if envelope.Type == "close" {
+					return
+				}
+				conn.WriteJSON(envelope)
+			}
+		}
+		for {
+			var ack socketModeAck
+			if conn.ReadJSON(&ack) != nil {
+				return
+			}
+			fake.acks <- ack.EnvelopeID
+		}
+	})
+	fake.Server = httptest.NewServer(mux)
+	t.Cleanup(fake.Close)
+
+	return fake
+}
+
+func newSlashCommandEnvelope(envelopeID string, text string, responseURL string) socketModeEnvelope {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"command":               "/bot",
+		"text":                  text,
+		"response_url":          responseURL,
+		"is_enterprise_install": false,
+	})
+
+	return socketModeEnvelope{Type: "slash_commands", EnvelopeID: envelopeID, Payload: payload}
+}
+
+func runSocketModeServer(server *SocketModeServer) (context.CancelFunc, chan error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Run(ctx, zap.NewNop())
+	}()
+
+	return cancel, errCh
+}
+
+func TestSocketModeDispatchesSlashCommands(t *testing.T) {
+	responseServer, responses := newResponseServer(t)
+	fake := newFakeSocketModeServer(t, []socketModeEnvelope{
+		{Type: "hello"},
+		newSlashCommandEnvelope("envelope-1", "echo hi", responseServer.URL),
+	})
+
+	var arguments []string
+	server := NewSocketModeServer("xapp-token", []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}})
+	server.apiURL = fake.URL + "/"
+	cancel, errCh := runSocketModeServer(server)
+
+	select {
+	case envelopeID := <-fake.acks:
+		if envelopeID != "envelope-1" {
+			t.Errorf("unexpected acknowledgement for %s", envelopeID)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("slash command envelope was never acknowledged")
+	}
+	if response := receiveResponse(t, responses); response.Text != "echo" {
+		t.Errorf("slash command was routed to %q", response.Text)
+	}
+
+	cancel()
+	if err := <-errCh; err != context.Canceled {
+		t.Errorf("expected the server to stop when cancelled, got %v", err)
+	}
+}
+
+func TestSocketModeReconnectsAfterDisconnect(t *testing.T) {
+	responseServer, responses := newResponseServer(t)
+	fake := newFakeSocketModeServer(t,
+		[]socketModeEnvelope{{Type: "hello"}, {Type: "disconnect", Reason: "refresh_requested"}},
+		[]socketModeEnvelope{{Type: "close"}},
+		[]socketModeEnvelope{{Type: "hello"}, newSlashCommandEnvelope("envelope-1", "echo hi", responseServer.URL)},
+	)
+
+	var arguments []string
+	server := NewSocketModeServer("xapp-token", []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}}, WithReconnectPolicy(testReconnectPolicy))
+	server.apiURL = fake.URL + "/"
+	cancel, errCh := runSocketModeServer(server)
+	defer func() {
+		cancel()
+		<-errCh
+	}()
+
+	if response := receiveResponse(t, responses); response.Text != "echo" {
+		t.Errorf("slash command was routed to %q", response.Text)
+	}
+	if connections := fake.connections.Load(); connections != 3 {
+		t.Errorf("expected 3 connections, got %d", connections)
+	}
+}
+
+func TestSocketModeGivesUpAfterMaxRetries(t *testing.T) {
+	fake := newFakeSocketModeServer(t)
+	server := NewSocketModeServer("xoxb-wrong", []SlackSlashCommandHandler{}, WithReconnectPolicy(testReconnectPolicy))
+	server.apiURL = fake.URL + "/"
+
+	err := server.Run(context.Background(), zap.NewNop())
+	if err == nil || !strings.Contains(err.Error(), "invalid_auth") {
+		t.Errorf("expected an invalid_auth error, got %v", err)
+	}
+}
+
+func TestReconnectBackoff(t *testing.T) {
+	policy := ReconnectPolicy{MinDelay: time.Second, MaxDelay: 4 * time.Second}
+	expectedCeilings := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second}
+	for i, ceiling := range expectedCeilings {
+		delay := policy.backoff(i + 1)
+		if delay < ceiling/2 || delay > ceiling {
+			t.Errorf("attempt %d backed off %v, expected between %v and %v", i+1, delay, ceiling/2, ceiling)
+		}
+	}
+}
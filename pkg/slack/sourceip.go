@@ -0,0 +1,79 @@
+package slack
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ParsePrefixes parses CIDR ranges such as 10.0.0.0/8, accepting bare
+// addresses as single-address ranges
+func ParsePrefixes(ranges []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(ranges))
+	for _, r := range ranges {
+		r = strings.TrimSpace(r)
+		if len(r) == 0 {
+			continue
+		}
+		if !strings.Contains(r, "/") {
+			addr, err := netip.ParseAddr(r)
+			if err != nil {
+				return nil, fmt.Errorf("invalid address %q: %w", r, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid range %q: %w", r, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
+
+// clientIP returns the address of the client that sent the request. The
+// X-Forwarded-For header is only trusted when the request comes from one
+// of the trusted proxies, in which case the right-most untrusted address
+// in it is the client.
+func clientIP(r *http.Request, trustedProxies []netip.Prefix) (netip.Addr, error) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("invalid remote address %q: %w", r.RemoteAddr, err)
+	}
+	addr = addr.Unmap()
+
+	// Walk the forwarded addresses from the closest hop while they are
+	// trusted proxies
+	forwarded := strings.Split(strings.Join(r.Header.Values("x-forwarded-for"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0 && containsAddr(trustedProxies, addr); i-- {
+		hop := strings.TrimSpace(forwarded[i])
+		if len(hop) == 0 {
+			continue
+		}
+		hopAddr, err := netip.ParseAddr(hop)
+		if err != nil {
+			return netip.Addr{}, fmt.Errorf("invalid x-forwarded-for address %q: %w", hop, err)
+		}
+		addr = hopAddr.Unmap()
+	}
+
+	return addr, nil
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,68 @@
+package slack
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestAllowedSourceRanges(t *testing.T) {
+	server, responses := newResponseServer(t)
+	var arguments []string
+	allowed, err := ParsePrefixes([]string{"203.0.113.0/24"})
+	if err != nil {
+		t.Fatalf("could not parse ranges: %v", err)
+	}
+	proxies, err := ParsePrefixes([]string{"10.0.0.1"})
+	if err != nil {
+		t.Fatalf("could not parse proxies: %v", err)
+	}
+	handler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{recordingHandler{"echo", &arguments}}, WithAllowedSourceRanges(allowed), WithTrustedProxies(proxies))
+	form := url.Values{
+		"text":         {"echo"},
+		"response_url": {server.URL},
+	}
+
+	cases := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		expectedCode int
+	}{
+		{"allowed source", "203.0.113.7:4321", "", http.StatusOK},
+		{"disallowed source", "198.51.100.7:4321", "", http.StatusForbidden},
+		{"allowed source behind a trusted proxy", "10.0.0.1:4321", "203.0.113.7", http.StatusOK},
+		{"disallowed source behind a trusted proxy", "10.0.0.1:4321", "198.51.100.7", http.StatusForbidden},
+		{"forwarded header from an untrusted proxy", "198.51.100.7:4321", "203.0.113.7", http.StatusForbidden},
+	}
+	for _, c := range cases {
+		r := newSignedRequest("abc", form)
+		r.RemoteAddr = c.remoteAddr
+		if len(c.forwardedFor) > 0 {
+			r.Header.Set("x-forwarded-for", c.forwardedFor)
+		}
+		w := httptest.NewRecorder()
+		handler(w, r)
+
+		if w.Code != c.expectedCode {
+			t.Errorf("%s: expected %d, got %d", c.name, c.expectedCode, w.Code)
+		}
+		if c.expectedCode == http.StatusOK {
+			if response := receiveResponse(t, responses); response.Text != "echo" {
+				t.Errorf("%s: unexpected response %q", c.name, response.Text)
+			}
+		}
+	}
+}
+
+func TestParsePrefixesRejectsInvalidRanges(t *testing.T) {
+	if _, err := ParsePrefixes([]string{"203.0.113.0/33"}); err == nil {
+		t.Errorf("expected an error for an invalid range")
+	}
+	if _, err := ParsePrefixes([]string{"not-an-address"}); err == nil {
+		t.Errorf("expected an error for an invalid address")
+	}
+}
@@ -0,0 +1,88 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SubcommandRouter is a handler that requires a subcommand, such as
+// `/bot db backup`, routing the remaining arguments to the matching
+// subcommand handler. Invoking it without a valid subcommand replies with
+// a usage message listing the subcommands.
+type SubcommandRouter struct {
+	name        string
+	description string
+	subcommands []SlackSlashCommandHandler
+}
+
+// NewSubcommandRouter creates a handler for the command name which
+// requires one of the given subcommands
+func NewSubcommandRouter(name string, description string, subcommands ...SlackSlashCommandHandler) SlackSlashCommandHandler {
+	return SubcommandRouter{name, description, subcommands}
+}
+
+func (h SubcommandRouter) Handle(arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	return h.HandleContext(context.Background(), arguments, request)
+}
+
+func (h SubcommandRouter) HandleContext(ctx context.Context, arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	if len(arguments) == 0 {
+		return nil, fmt.Errorf("%s requires a subcommand\n%s", h.name, h.usage())
+	}
+	caseSensitive := caseSensitiveCommandsFromContext(ctx)
+	for _, subcommand := range h.subcommands {
+		if matchesCommand(subcommand.CommandName(), arguments[0], caseSensitive) {
+			return invoke(ctx, subcommand, arguments[1:], request)
+		}
+	}
+
+	return nil, fmt.Errorf("%s is not a subcommand of %s\n%s", arguments[0], h.name, h.usage())
+}
+
+type caseSensitiveCommandsContextKey struct{}
+
+// withCaseSensitiveCommands lets handlers routing commands themselves,
+// such as SubcommandRouter, match them like the bot does
+func withCaseSensitiveCommands(ctx context.Context, caseSensitive bool) context.Context {
+	return context.WithValue(ctx, caseSensitiveCommandsContextKey{}, caseSensitive)
+}
+
+// caseSensitiveCommandsFromContext reports whether commands are matched
+// case sensitively, which they aren't by default
+func caseSensitiveCommandsFromContext(ctx context.Context) bool {
+	caseSensitive, _ := ctx.Value(caseSensitiveCommandsContextKey{}).(bool)
+	return caseSensitive
+}
+
+// usage lists the subcommands along with their arguments and descriptions
+func (h SubcommandRouter) usage() string {
+	var usage strings.Builder
+	usage.WriteString(UsageString(h))
+	usage.WriteString("\nSubcommands:")
+	for _, subcommand := range h.subcommands {
+		fmt.Fprintf(&usage, "\n%s", strings.TrimSpace(fmt.Sprintf("%s %s", subcommand.CommandName(), subcommand.CommandArguments())))
+		if description := subcommand.CommandDescription(); len(description) > 0 {
+			fmt.Fprintf(&usage, " - %s", description)
+		}
+	}
+
+	return usage.String()
+}
+
+func (h SubcommandRouter) CommandName() string {
+	return h.name
+}
+
+func (h SubcommandRouter) CommandArguments() string {
+	names := make([]string, 0, len(h.subcommands))
+	for _, subcommand := range h.subcommands {
+		names = append(names, subcommand.CommandName())
+	}
+
+	return fmt.Sprintf("<%s> [arguments...]", strings.Join(names, "|"))
+}
+
+func (h SubcommandRouter) CommandDescription() string {
+	return h.description
+}
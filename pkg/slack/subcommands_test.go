@@ -0,0 +1,75 @@
+package slack
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type subcommandHandler struct {
+	describedHandler
+	received *[]string
+}
+
+func (h subcommandHandler) Handle(arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	*h.received = arguments
+	return nil, nil
+}
+
+func TestSubcommandRouter(t *testing.T) {
+	var backupArguments, restoreArguments []string
+	bot := NewSlackBot(0, NewStaticSecretSource("", ""), []SlackSlashCommandHandler{
+		NewSubcommandRouter("db", "Manages the database",
+			subcommandHandler{describedHandler{"backup", "[name]", "Backs up the database"}, &backupArguments},
+			subcommandHandler{describedHandler{"restore", "<name>", "Restores a backup"}, &restoreArguments},
+		),
+	})
+
+	// Without a subcommand the usage is returned
+	_, err := bot.InvokeCommand(context.Background(), "db", SlackSlashCommandBody{})
+	if err == nil {
+		t.Fatalf("expected an error without a subcommand")
+	}
+	for _, expected := range []string{"db requires a subcommand", "Usage: db <backup|restore> [arguments...]", "backup [name] - Backs up the database", "restore <name> - Restores a backup"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected usage to contain %q, got %q", expected, err.Error())
+		}
+	}
+
+	// Unknown subcommands also get the usage
+	_, err = bot.InvokeCommand(context.Background(), "db drop", SlackSlashCommandBody{})
+	if err == nil || !strings.Contains(err.Error(), "drop is not a subcommand of db") {
+		t.Errorf("expected an unknown subcommand error, got %v", err)
+	}
+
+	// Subcommands receive the remaining arguments
+	_, err = bot.InvokeCommand(context.Background(), "db Restore nightly", SlackSlashCommandBody{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(restoreArguments) != 1 || restoreArguments[0] != "nightly" || backupArguments != nil {
+		t.Errorf("expected restore to receive [nightly], got %v", restoreArguments)
+	}
+}
+
+func TestSubcommandRouterHonoursCaseSensitivity(t *testing.T) {
+	var backupArguments []string
+	handlers := []SlackSlashCommandHandler{
+		NewSubcommandRouter("db", "Manages the database",
+			subcommandHandler{describedHandler{"backup", "[name]", "Backs up the database"}, &backupArguments},
+		),
+	}
+
+	insensitive := NewSlackBot(0, NewStaticSecretSource("", ""), handlers)
+	if _, err := insensitive.InvokeCommand(context.Background(), "db BACKUP nightly", SlackSlashCommandBody{}); err != nil {
+		t.Errorf("expected subcommands to match regardless of case by default, got %v", err)
+	}
+
+	sensitive := NewSlackBot(0, NewStaticSecretSource("", ""), handlers, WithCaseSensitiveCommands(true))
+	if _, err := sensitive.InvokeCommand(context.Background(), "db BACKUP nightly", SlackSlashCommandBody{}); err == nil {
+		t.Errorf("expected BACKUP not to match backup with case sensitive commands")
+	}
+	if _, err := sensitive.InvokeCommand(context.Background(), "db backup nightly", SlackSlashCommandBody{}); err != nil {
+		t.Errorf("expected backup to match with case sensitive commands, got %v", err)
+	}
+}
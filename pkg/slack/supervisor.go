@@ -0,0 +1,69 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const defaultDrainTimeout = 10 * time.Second
+
+// Supervisor runs a single SlackBot at a time and swaps it for a new one
+// when the configuration changes. The running bot is drained before its
+// replacement binds the port, so in-flight requests complete but new
+// connections are refused for the brief window between the two.
+type Supervisor struct {
+	logger  *zap.Logger
+	lock    sync.Mutex
+	current *SlackBot
+	errCh   chan error
+}
+
+func NewSupervisor(logger *zap.Logger) *Supervisor {
+	return &Supervisor{
+		logger: logger,
+		errCh:  make(chan error),
+	}
+}
+
+// Apply gracefully shuts down the running bot, if any, waiting up to
+// drainTimeout for its in-flight requests, and starts the given one in
+// the background. A zero drainTimeout uses a default of ten seconds.
+func (s *Supervisor) Apply(bot SlackBot, drainTimeout time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Drain the running bot
+	if s.current != nil {
+		if drainTimeout <= 0 {
+			drainTimeout = defaultDrainTimeout
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+		defer cancel()
+		err := s.current.Shutdown(ctx)
+		if err != nil {
+			s.logger.Error("server did not drain in time", zap.Error(err))
+		} else {
+			s.logger.Info("server has shutdown normally")
+		}
+	}
+
+	// Start the new bot, forwarding any error other than a normal shutdown
+	s.current = &bot
+	go func() {
+		s.logger.Info("starting server", zap.Uint16("port", bot.port))
+		err := bot.ListenAndServe(s.logger)
+		if !errors.Is(err, http.ErrServerClosed) {
+			s.errCh <- err
+		}
+	}()
+}
+
+// Errors returns a channel receiving errors from bots that failed to serve
+func (s *Supervisor) Errors() <-chan error {
+	return s.errCh
+}
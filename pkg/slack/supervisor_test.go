@@ -0,0 +1,96 @@
+package slack
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type blockingHandler struct {
+	recordingHandler
+	started chan struct{}
+	release chan struct{}
+}
+
+func (h blockingHandler) Handle(arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	close(h.started)
+	<-h.release
+	return h.recordingHandler.Handle(arguments, request)
+}
+
+func freePort(t *testing.T) uint16 {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not find a free port: %v", err)
+	}
+	defer listener.Close()
+
+	return uint16(listener.Addr().(*net.TCPAddr).Port)
+}
+
+func postToBot(port uint16, r *http.Request) (*http.Response, error) {
+	r.RequestURI = ""
+	r.URL, _ = url.Parse(fmt.Sprintf("http://127.0.0.1:%d/", port))
+	return http.DefaultClient.Do(r)
+}
+
+func TestSupervisorDrainsInFlightRequests(t *testing.T) {
+	server, responses := newResponseServer(t)
+	port := freePort(t)
+	supervisor := NewSupervisor(zap.NewNop())
+	var arguments []string
+	slow := blockingHandler{recordingHandler{"slow", &arguments}, make(chan struct{}), make(chan struct{})}
+	supervisor.Apply(NewSlackBot(port, NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{slow}), 0)
+
+	// Start a request and wait for it to reach the handler, retrying until
+	// the first bot is listening
+	statusCh := make(chan int)
+	go func() {
+		for {
+			resp, err := postToBot(port, newSignedRequest("abc", url.Values{
+				"text":         {"slow"},
+				"response_url": {server.URL},
+			}))
+			if err != nil {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			resp.Body.Close()
+			statusCh <- resp.StatusCode
+			return
+		}
+	}()
+	select {
+	case <-slow.started:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("request never reached the first bot")
+	}
+
+	// Push a new configuration while the request is in flight
+	applied := make(chan struct{})
+	go func() {
+		supervisor.Apply(NewSlackBot(port, NewStaticSecretSource("def", ""), []SlackSlashCommandHandler{}), 0)
+		close(applied)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case <-applied:
+		t.Fatalf("new configuration was applied before the in-flight request completed")
+	default:
+	}
+
+	// Let the in-flight request complete and ensure it was fully served
+	close(slow.release)
+	if status := <-statusCh; status != http.StatusOK {
+		t.Errorf("in-flight request completed with status %d", status)
+	}
+	if response := receiveResponse(t, responses); response.Text != "slow" {
+		t.Errorf("unexpected response %q", response.Text)
+	}
+	<-applied
+}
@@ -0,0 +1,95 @@
+package slack
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Slack limits the text of a section block
+const maxSectionTextLength = 3000
+
+// Cells wider than this are truncated so that tables stay readable
+const maxTableColumnWidth = 40
+
+// TableResponse renders headers and rows as a table aligned in a code
+// block, in a single section. Cells are truncated to keep columns
+// narrow, and rows that don't fit in the section are left out with a
+// note saying how many there were.
+func TableResponse(responseType string, headers []string, rows [][]string) *SlackResponse {
+	// Clean up every cell and work out the width of each column
+	columns := len(headers)
+	for _, row := range rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+	widths := make([]int, columns)
+	clean := func(row []string) []string {
+		cells := make([]string, columns)
+		for i := range cells {
+			if i < len(row) {
+				cells[i] = tableCell(row[i])
+			}
+			if width := utf8.RuneCountInString(cells[i]); width > widths[i] {
+				widths[i] = width
+			}
+		}
+		return cells
+	}
+	lines := [][]string{clean(headers), make([]string, columns)}
+	for _, row := range rows {
+		lines = append(lines, clean(row))
+	}
+
+	// Underline the headers once the widths are known
+	for i, width := range widths {
+		lines[1][i] = strings.Repeat("-", width)
+	}
+
+	// Render the lines, leaving out rows once the section is full
+	table := ""
+	for i, line := range lines {
+		// Keep room for the note unless this is the last row
+		rendered := tableLine(line, widths) + "\n"
+		omitted := fmt.Sprintf("... %d more rows\n", len(lines)-i)
+		reserved := omitted
+		if i == len(lines)-1 {
+			reserved = ""
+		}
+		if len("```\n"+table+rendered+reserved+"```") > maxSectionTextLength {
+			table += omitted
+			break
+		}
+		table += rendered
+	}
+	text := "```\n" + table + "```"
+
+	return &SlackResponse{
+		ResponseType: responseType,
+		Text:         text,
+		Blocks:       []Block{NewSectionBlock(NewMarkdownText(text))},
+	}
+}
+
+// tableCell keeps a cell on a single line, without backticks that would
+// end the code block, truncating it when it is too wide
+func tableCell(cell string) string {
+	cell = strings.Join(strings.Fields(cell), " ")
+	cell = strings.ReplaceAll(cell, "`", "'")
+	if utf8.RuneCountInString(cell) > maxTableColumnWidth {
+		cell = string([]rune(cell)[:maxTableColumnWidth-1]) + "…"
+	}
+
+	return cell
+}
+
+// tableLine pads each cell to the width of its column
+func tableLine(cells []string, widths []int) string {
+	padded := make([]string, len(cells))
+	for i, cell := range cells {
+		padded[i] = cell + strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell))
+	}
+
+	return strings.TrimRight(strings.Join(padded, "  "), " ")
+}
@@ -0,0 +1,50 @@
+package slack
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTableResponseAlignsColumns(t *testing.T) {
+	response := TableResponse("ephemeral", []string{"service", "version", "status"}, [][]string{
+		{"api", "1.2.3", "healthy"},
+		{"billing-worker", "10.0.0-rc.1", "degraded"},
+		{"web", "2"},
+	})
+
+	expected := "```\n" +
+		"service         version      status\n" +
+		"--------------  -----------  --------\n" +
+		"api             1.2.3        healthy\n" +
+		"billing-worker  10.0.0-rc.1  degraded\n" +
+		"web             2\n" +
+		"```"
+	if response.Text != expected {
+		t.Errorf("unexpected table\n%s\nexpected\n%s", response.Text, expected)
+	}
+	if len(response.Blocks) != 1 || response.Blocks[0].Type != "section" || response.Blocks[0].Text.Text != expected {
+		t.Errorf("expected the table in a single section, got %+v", response.Blocks)
+	}
+}
+
+func TestTableResponseTruncates(t *testing.T) {
+	long := strings.Repeat("x", 100)
+	rows := [][]string{}
+	for i := 0; i < 200; i++ {
+		rows = append(rows, []string{long, "`value`"})
+	}
+	response := TableResponse("ephemeral", []string{"key", "value"}, rows)
+
+	if len(response.Text) > maxSectionTextLength {
+		t.Errorf("expected the table to fit in a section, it is %d long", len(response.Text))
+	}
+	if strings.Contains(response.Text, long) {
+		t.Errorf("expected wide cells to be truncated")
+	}
+	if strings.Count(response.Text, "`") != 6 {
+		t.Errorf("expected backticks in cells not to end the code block")
+	}
+	if !strings.Contains(response.Text, "more rows\n```") {
+		t.Errorf("expected a note about the rows left out, got %q", response.Text[len(response.Text)-40:])
+	}
+}
@@ -0,0 +1,110 @@
+package slack
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// ResponseTemplate renders response text from a text/template, so that
+// handlers can keep their output's wording in one place and only supply
+// the data. Templates can use the mrkdwn helpers in MarkdownFuncs, such
+// as {{ bold .Name }} or {{ user .UserID }}.
+type ResponseTemplate struct {
+	template *template.Template
+}
+
+// MarkdownFuncs are the helpers available to every ResponseTemplate for
+// formatting Slack mrkdwn. Text passed to them is escaped, except for
+// code, which Slack doesn't interpret and only has its backticks
+// replaced so that it can't end the code span early.
+var MarkdownFuncs = template.FuncMap{
+	"escape":    EscapeMarkdown,
+	"bold":      func(text string) string { return "*" + EscapeMarkdown(text) + "*" },
+	"italic":    func(text string) string { return "_" + EscapeMarkdown(text) + "_" },
+	"strike":    func(text string) string { return "~" + EscapeMarkdown(text) + "~" },
+	"code":      func(text string) string { return "`" + strings.ReplaceAll(text, "`", "'") + "`" },
+	"codeblock": func(text string) string { return "```\n" + strings.ReplaceAll(text, "```", "'''") + "\n```" },
+	"quote":     markdownQuote,
+	"link":      markdownLink,
+	"user":      func(id string) string { return fmt.Sprintf("<@%s>", id) },
+	"channel":   func(id string) string { return fmt.Sprintf("<#%s>", id) },
+	"bullets":   markdownBullets,
+}
+
+// EscapeMarkdown escapes the characters Slack uses for control sequences
+// in mrkdwn, so that text is shown as typed
+func EscapeMarkdown(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// markdownLink links to url, percent-encoding the characters that would
+// end the URL early or corrupt it
+func markdownLink(url string, text string) string {
+	url = strings.NewReplacer("|", "%7C", "<", "%3C", ">", "%3E").Replace(url)
+	return fmt.Sprintf("<%s|%s>", url, EscapeMarkdown(text))
+}
+
+func markdownQuote(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = "> " + EscapeMarkdown(line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func markdownBullets(items []string) string {
+	lines := make([]string, len(items))
+	for i, item := range items {
+		lines[i] = "• " + EscapeMarkdown(item)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// NewResponseTemplate parses text as a template named name
+func NewResponseTemplate(name string, text string) (*ResponseTemplate, error) {
+	parsed, err := template.New(name).Funcs(MarkdownFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response template %s: %w", name, err)
+	}
+
+	return &ResponseTemplate{parsed}, nil
+}
+
+// MustResponseTemplate is like NewResponseTemplate but panics if text is
+// invalid, for templates defined in package variables
+func MustResponseTemplate(name string, text string) *ResponseTemplate {
+	responseTemplate, err := NewResponseTemplate(name, text)
+	if err != nil {
+		panic(err)
+	}
+
+	return responseTemplate
+}
+
+// Render executes the template with data
+func (t *ResponseTemplate) Render(data interface{}) (string, error) {
+	var text strings.Builder
+	err := t.template.Execute(&text, data)
+	if err != nil {
+		return "", fmt.Errorf("could not render response: %w", err)
+	}
+
+	return text.String(), nil
+}
+
+// Response renders the template with data into a response of the given
+// type, such as `in_channel`
+func (t *ResponseTemplate) Response(responseType string, data interface{}) (*SlackResponse, error) {
+	text, err := t.Render(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SlackResponse{
+		ResponseType: responseType,
+		Text:         text,
+	}, nil
+}
@@ -0,0 +1,66 @@
+package slack
+
+import (
+	"testing"
+)
+
+type deployHandler struct {
+	recordingHandler
+}
+
+var deployTemplate = MustResponseTemplate("deploy", `{{ user .UserID }} deployed {{ bold .Service }} to {{ code .Environment }}
+{{ bullets .Changes }}
+{{ link .URL "View the pipeline" }}`)
+
+func (h deployHandler) Handle(arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	return deployTemplate.Response("in_channel", map[string]interface{}{
+		"UserID":      request.UserID,
+		"Service":     arguments[0],
+		"Environment": arguments[1],
+		"Changes":     []string{"Fix login", "Bump <deps> & tools"},
+		"URL":         "https://ci.example.com/1",
+	})
+}
+
+func TestHandlerRendersTemplate(t *testing.T) {
+	response, err := deployHandler{recordingHandler{name: "deploy"}}.Handle([]string{"api", "prod"}, SlackSlashCommandBody{UserID: "U123"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "<@U123> deployed *api* to `prod`\n• Fix login\n• Bump &lt;deps&gt; &amp; tools\n<https://ci.example.com/1|View the pipeline>"
+	if response.Text != expected {
+		t.Errorf("unexpected text\n%s\nexpected\n%s", response.Text, expected)
+	}
+	if response.ResponseType != "in_channel" {
+		t.Errorf("unexpected response type %q", response.ResponseType)
+	}
+}
+
+func TestTemplateRejectsMissingData(t *testing.T) {
+	_, err := deployTemplate.Render(map[string]interface{}{"UserID": "U123"})
+	if err == nil {
+		t.Error("expected an error for missing data")
+	}
+	if _, err := NewResponseTemplate("broken", "{{ bold }"); err == nil {
+		t.Error("expected an error for an invalid template")
+	}
+}
+
+func TestTemplateKeepsUserTextInsideCodeAndLinks(t *testing.T) {
+	tmpl := MustResponseTemplate("escaping", "{{ code .Code }} {{ link .URL .Text }}\n{{ codeblock .Block }}")
+	text, err := tmpl.Render(map[string]string{
+		"Code":  "a` *bold* `b",
+		"URL":   "https://example.com/?q=a|b>c",
+		"Text":  "docs",
+		"Block": "x\n```\ny",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "`a' *bold* 'b` <https://example.com/?q=a%7Cb%3Ec|docs>\n```\nx\n'''\ny\n```"
+	if text != expected {
+		t.Errorf("unexpected text\n%s\nexpected\n%s", text, expected)
+	}
+}
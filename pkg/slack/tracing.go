@@ -0,0 +1,30 @@
+package slack
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/pauwels-labs/slack-bot/pkg/slack"
+
+// Span attributes describing the command being handled
+const (
+	commandAttribute = attribute.Key("slack.command")
+	outcomeAttribute = attribute.Key("slack.outcome")
+)
+
+// startSpan starts a child of the span in ctx using the same tracer
+// provider, which is a no-op unless one was set with WithTracerProvider
+func startSpan(ctx context.Context, name string, options ...trace.SpanStartOption) (context.Context, trace.Span) {
+	tracer := trace.SpanFromContext(ctx).TracerProvider().Tracer(tracerName)
+	return tracer.Start(ctx, name, options...)
+}
+
+// failSpan records err on span and marks it as failed
+func failSpan(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,71 @@
+package slack
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type spanRecordingHandler struct {
+	recordingHandler
+	spans chan trace.SpanContext
+}
+
+func (h spanRecordingHandler) HandleContext(ctx context.Context, arguments []string, request SlackSlashCommandBody) (*SlackResponse, error) {
+	h.spans <- trace.SpanFromContext(ctx).SpanContext()
+	return &SlackResponse{Text: h.name}, nil
+}
+
+func TestRequestsAreTraced(t *testing.T) {
+	server, responses := newResponseServer(t)
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	handler := spanRecordingHandler{recordingHandler{name: "echo"}, make(chan trace.SpanContext, 1)}
+	buildHandler := BuildHandler(zap.NewNop(), NewStaticSecretSource("abc", ""), []SlackSlashCommandHandler{handler}, WithTracerProvider(provider))
+
+	buildHandler(httptest.NewRecorder(), newSignedRequest("abc", url.Values{
+		"text":         {"echo hi"},
+		"response_url": {server.URL},
+	}))
+	receiveResponse(t, responses)
+	handlerSpan := <-handler.spans
+
+	spans := map[string]tracetest.SpanStub{}
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = span
+	}
+	for _, name := range []string{"slack.request", "slack.verify", "slack.dispatch", "slack.respond"} {
+		if _, ok := spans[name]; !ok {
+			t.Fatalf("expected a %s span, got %v", name, spans)
+		}
+	}
+
+	// Spans form a single trace under the request
+	request := spans["slack.request"]
+	for _, name := range []string{"slack.verify", "slack.dispatch"} {
+		if spans[name].Parent.SpanID() != request.SpanContext.SpanID() {
+			t.Errorf("expected %s to be a child of the request span", name)
+		}
+	}
+	dispatch := spans["slack.dispatch"]
+	if spans["slack.respond"].Parent.SpanID() != dispatch.SpanContext.SpanID() {
+		t.Error("expected the respond span to be a child of the dispatch span")
+	}
+	if handlerSpan.SpanID() != dispatch.SpanContext.SpanID() {
+		t.Error("expected the handler's context to carry the dispatch span")
+	}
+
+	attributes := map[string]string{}
+	for _, attribute := range dispatch.Attributes {
+		attributes[string(attribute.Key)] = attribute.Value.AsString()
+	}
+	if attributes["slack.command"] != "echo" || attributes["slack.outcome"] != "success" {
+		t.Errorf("unexpected dispatch attributes %v", attributes)
+	}
+}
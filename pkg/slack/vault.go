@@ -0,0 +1,158 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How long secrets read from Vault are used before being read again
+const defaultVaultRefreshInterval = 5 * time.Minute
+
+// How long to wait before reading from Vault again after a failed read,
+// and how long a read may take
+const (
+	vaultRetryInterval = 30 * time.Second
+	vaultReadTimeout   = 5 * time.Second
+)
+
+// VaultSecretSource reads the bot's secrets from a HashiCorp Vault KV
+// secret, which holds them under the `signingkey` and `bottoken` keys.
+// Secrets are read again in the background once the refresh interval has
+// passed so that rotated secrets are picked up without holding up
+// requests, and the last secrets read keep being provided while Vault is
+// unreachable. Failed reads are retried after a short delay rather than
+// on every call.
+type VaultSecretSource struct {
+	address         string
+	token           string
+	path            string
+	refreshInterval time.Duration
+	now             func() time.Time
+	lock            sync.Mutex
+	secrets         map[string]string
+	// nextRead is when Vault should be read again, and err the error of
+	// the last read if no secrets could be read yet
+	nextRead   time.Time
+	err        error
+	refreshing bool
+}
+
+type vaultSecretResponse struct {
+	Data map[string]interface{} `json:"data"`
+}
+
+// NewVaultSecretSource creates a source reading the secret at path, such
+// as `secret/data/slack-bot` for a KV version 2 engine mounted at
+// `secret`, from the Vault server at address using the given token
+func NewVaultSecretSource(address string, token string, path string, refreshInterval time.Duration) *VaultSecretSource {
+	if refreshInterval <= 0 {
+		refreshInterval = defaultVaultRefreshInterval
+	}
+
+	return &VaultSecretSource{
+		address:         strings.TrimSuffix(address, "/"),
+		token:           token,
+		path:            strings.Trim(path, "/"),
+		refreshInterval: refreshInterval,
+		now:             time.Now,
+	}
+}
+
+func (v *VaultSecretSource) SigningKey() (string, error) {
+	return v.secret("signingkey")
+}
+
+func (v *VaultSecretSource) BotToken() (string, error) {
+	return v.secret("bottoken")
+}
+
+func (v *VaultSecretSource) secret(key string) (string, error) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	// Without secrets there is nothing else to provide, so they are read
+	// right away unless the last attempt failed too recently
+	due := !v.now().Before(v.nextRead)
+	if v.secrets == nil {
+		if due {
+			v.store(v.read())
+		}
+		if v.secrets == nil {
+			return "", v.err
+		}
+		return v.secrets[key], nil
+	}
+
+	// Otherwise refresh them in the background, providing those already
+	// read in the meantime
+	if due && !v.refreshing {
+		v.refreshing = true
+		v.nextRead = v.now().Add(vaultRetryInterval)
+		go func() {
+			secrets, err := v.read()
+			v.lock.Lock()
+			defer v.lock.Unlock()
+			v.refreshing = false
+			v.store(secrets, err)
+		}()
+	}
+
+	return v.secrets[key], nil
+}
+
+// store keeps the result of a read, scheduling the next one, and must be
+// called with the lock held
+func (v *VaultSecretSource) store(secrets map[string]string, err error) {
+	if err != nil {
+		v.err = err
+		v.nextRead = v.now().Add(min(vaultRetryInterval, v.refreshInterval))
+		return
+	}
+	v.secrets = secrets
+	v.err = nil
+	v.nextRead = v.now().Add(v.refreshInterval)
+}
+
+func (v *VaultSecretSource) read() (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), vaultReadTimeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/v1/%s", v.address, v.path), nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("x-vault-token", v.token)
+
+	response, err := outboundClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("could not read secrets from vault: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not read secrets from vault: %s returned status %d", v.path, response.StatusCode)
+	}
+
+	var body vaultSecretResponse
+	err = json.NewDecoder(response.Body).Decode(&body)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode secrets from vault: %w", err)
+	}
+
+	// KV version 2 nests the secret's data under another data key
+	data := body.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+	secrets := map[string]string{}
+	for key, value := range data {
+		if text, ok := value.(string); ok {
+			secrets[key] = text
+		}
+	}
+
+	return secrets, nil
+}
@@ -0,0 +1,144 @@
+package slack
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newVaultServer(t *testing.T, signingKey *atomic.Value, status *atomic.Int32, requests *atomic.Int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path != "/v1/secret/data/slack-bot" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		if r.Header.Get("x-vault-token") != "s.test" {
+			t.Errorf("unexpected vault token %q", r.Header.Get("x-vault-token"))
+		}
+		if code := status.Load(); code != 0 {
+			w.WriteHeader(int(code))
+			return
+		}
+		fmt.Fprintf(w, `{"data":{"data":{"signingkey":%q,"bottoken":"xoxb-test"},"metadata":{"version":1}}}`, signingKey.Load())
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestVaultSecretSourceLoadsAndRefreshes(t *testing.T) {
+	var signingKey atomic.Value
+	var status, requests atomic.Int32
+	signingKey.Store("first")
+	server := newVaultServer(t, &signingKey, &status, &requests)
+	now := time.Now()
+	source := NewVaultSecretSource(server.URL+"/", "s.test", "/secret/data/slack-bot", time.Minute)
+	source.now = func() time.Time {
+		return now
+	}
+
+	key, err := source.SigningKey()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key != "first" {
+		t.Errorf("expected the signing key to be loaded, got %q", key)
+	}
+	token, err := source.BotToken()
+	if err != nil || token != "xoxb-test" {
+		t.Errorf("expected the bot token to be loaded, got %q, %v", token, err)
+	}
+
+	// Rotated secrets aren't read until the refresh interval passes
+	signingKey.Store("second")
+	if key, _ := source.SigningKey(); key != "first" {
+		t.Errorf("expected the signing key to be cached, got %q", key)
+	}
+	now = now.Add(time.Minute)
+	if key, _ := source.SigningKey(); key != "first" {
+		t.Errorf("expected the cached signing key while refreshing in the background, got %q", key)
+	}
+	waitForSigningKey(t, source, "second")
+
+	// The last secrets read are kept while vault is unavailable, which
+	// is only asked again once the retry interval has passed
+	status.Store(http.StatusServiceUnavailable)
+	signingKey.Store("third")
+	now = now.Add(time.Minute)
+	before := requests.Load()
+	for i := 0; i < 5; i++ {
+		key, err = source.SigningKey()
+		if err != nil || key != "second" {
+			t.Errorf("expected the last signing key to be kept, got %q, %v", key, err)
+		}
+	}
+	waitForRequests(t, &requests, before+1)
+	source.SigningKey()
+	time.Sleep(10 * time.Millisecond)
+	if made := requests.Load() - before; made != 1 {
+		t.Errorf("expected a single read of vault during the outage, got %d", made)
+	}
+	status.Store(0)
+	now = now.Add(vaultRetryInterval)
+	waitForSigningKey(t, source, "third")
+}
+
+// waitForSigningKey waits for a background refresh to provide the
+// expected signing key
+func waitForSigningKey(t *testing.T, source *VaultSecretSource, expected string) {
+	deadline := time.Now().Add(time.Second)
+	for {
+		key, _ := source.SigningKey()
+		if key == expected {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the signing key to be refreshed to %q, got %q", expected, key)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func waitForRequests(t *testing.T, requests *atomic.Int32, expected int32) {
+	deadline := time.Now().Add(time.Second)
+	for requests.Load() < expected {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d requests to vault, got %d", expected, requests.Load())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestVaultSecretSourceFailsWithoutSecrets(t *testing.T) {
+	var signingKey atomic.Value
+	var status, requests atomic.Int32
+	signingKey.Store("first")
+	status.Store(http.StatusForbidden)
+	server := newVaultServer(t, &signingKey, &status, &requests)
+	source := NewVaultSecretSource(server.URL, "s.test", "secret/data/slack-bot", 0)
+
+	if _, err := source.SigningKey(); err == nil {
+		t.Error("expected an error when vault refuses the first read")
+	}
+
+	// Failed reads aren't retried on every call, only after a delay
+	now := time.Now()
+	source.now = func() time.Time {
+		return now
+	}
+	source.nextRead = now.Add(vaultRetryInterval)
+	if _, err := source.SigningKey(); err == nil {
+		t.Error("expected the error to be kept until the read is retried")
+	}
+	if requests.Load() != 1 {
+		t.Errorf("expected vault not to be read again before the retry interval, got %d reads", requests.Load())
+	}
+	status.Store(0)
+	now = now.Add(vaultRetryInterval)
+	if key, err := source.SigningKey(); err != nil || key != "first" {
+		t.Errorf("expected the signing key once vault is available, got %q, %v", key, err)
+	}
+}